@@ -0,0 +1,177 @@
+package bluetooth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AudioDetector tells whether the audio of a connected device can be played. An error means that the state is
+// unknown, e.g. because a command failed.
+type AudioDetector interface {
+	Available(address string) (bool, error)
+}
+
+// AudioDetectorFunc is a function that implements AudioDetector
+type AudioDetectorFunc func(address string) (bool, error)
+
+func (f AudioDetectorFunc) Available(address string) (bool, error) {
+	return f(address)
+}
+
+// the audio backends and the commands that list their outputs
+var audioBackends = map[string][][]string{
+	// PulseAudio ("bluez_sink.AA_BB_...") and PipeWire ("bluez_output.AA_BB_...")
+	"pulse": {{"pactl", "list", "short", "sinks"}},
+	// bluez-alsa 4 ("/org/bluealsa/hci0/dev_AA_BB_.../a2dpsrc/sink") and older ("bluealsa:DEV=AA:BB:...")
+	"bluealsa": {{"bluealsa-cli", "list-pcms"}, {"bluealsa-aplay", "-L"}},
+}
+
+// AudioChecker looks for the output of a device in the audio backends. The backends that aren't installed or
+// running are ignored; when no backend is usable, every device is available. A backend that has answered once and
+// fails later returns an error instead of being ignored. It's safe for concurrent use.
+type AudioChecker struct {
+	run      Runner
+	backends []string
+	mutex    sync.Mutex
+	usable   map[string]bool // backends that have answered
+}
+
+/**
+  Returns a checker for the given backends ("pulse", "bluealsa")
+*/
+func NewAudioChecker(run Runner, backends ...string) (*AudioChecker, error) {
+	if run == nil {
+		run = ExecRunner
+	}
+	for _, b := range backends {
+		if _, ok := audioBackends[b]; !ok {
+			return nil, fmt.Errorf("unknown audio backend %q", b)
+		}
+	}
+	return &AudioChecker{run: run, backends: backends, usable: map[string]bool{}}, nil
+}
+
+func (c *AudioChecker) Available(address string) (bool, error) {
+	usable := false
+	for _, b := range c.backends {
+		out, err := c.list(b)
+		if err != nil {
+			c.mutex.Lock()
+			known := c.usable[b]
+			c.mutex.Unlock()
+			if known {
+				return false, err
+			}
+			continue
+		}
+		c.mutex.Lock()
+		c.usable[b] = true
+		c.mutex.Unlock()
+		usable = true
+		if listsDevice(out, address) {
+			return true, nil
+		}
+	}
+	return !usable, nil
+}
+
+// returns the outputs of the backend from the first command that succeeds
+func (c *AudioChecker) list(backend string) (string, error) {
+	var err error
+	for _, cmd := range audioBackends[backend] {
+		var out []byte
+		if out, err = c.run(cmd[0], cmd[1:]...); err == nil {
+			return string(out), nil
+		}
+	}
+	return "", err
+}
+
+// returns true if the output of a backend contains the address, written with colons or underscores
+func listsDevice(out, address string) bool {
+	if address == "" {
+		return false
+	}
+	out = strings.ReplaceAll(strings.ToUpper(out), "_", ":")
+	return strings.Contains(out, strings.ToUpper(address))
+}
+
+// AudioConnected returns the address of the first device that is connected and whose audio output is available.
+// An error of the detector is returned, the state is unknown then.
+func AudioConnected(devices []Device, d AudioDetector) (string, bool, error) {
+	for _, dev := range devices {
+		if !dev.Connected {
+			continue
+		}
+		available, err := d.Available(dev.Address)
+		if err != nil {
+			return "", false, err
+		}
+		if available {
+			return dev.Address, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// State is the connection state of the paired devices. It's updated with the events of the manager, so that the
+// devices don't have to be polled. It's safe for concurrent use.
+type State struct {
+	mutex   sync.Mutex
+	devices []Device
+}
+
+/**
+  Returns the state of the given devices, e.g. the result of Manager.Devices
+*/
+func NewState(devices []Device) *State {
+	return &State{devices: append([]Device(nil), devices...)}
+}
+
+// Devices returns the devices in their order
+func (s *State) Devices() []Device {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Device(nil), s.devices...)
+}
+
+// Add appends the device, e.g. after it was paired. Returns false, if the device is already known.
+func (s *State) Add(d Device) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, known := range s.devices {
+		if strings.EqualFold(known.Address, d.Address) {
+			return false
+		}
+	}
+	s.devices = append(s.devices, d)
+	return true
+}
+
+// Update applies the event. Returns false, if the device is unknown (e.g. not an audio sink) or its state hasn't
+// changed.
+func (s *State) Update(e Event) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, d := range s.devices {
+		if strings.EqualFold(d.Address, e.Address) {
+			changed := d.Connected != e.Connected
+			s.devices[i].Connected = e.Connected
+			return changed
+		}
+	}
+	return false
+}
+
+// Connected returns true if the device is known and connected
+func (s *State) Connected(address string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, d := range s.devices {
+		if strings.EqualFold(d.Address, address) {
+			return d.Connected
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package bluetooth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// returns a runner that answers with the given outputs; missing commands fail
+func fakeRunner(outputs map[string]string) Runner {
+	return func(name string, args ...string) ([]byte, error) {
+		out, ok := outputs[name+" "+strings.Join(args, " ")]
+		if !ok {
+			return nil, fmt.Errorf("%s: not found", name)
+		}
+		return []byte(out), nil
+	}
+}
+
+func TestAudioChecker(t *testing.T) {
+	const addr = "AA:BB:CC:DD:EE:FF"
+	tests := []struct {
+		name     string
+		backends []string
+		outputs  map[string]string
+		want     bool
+	}{
+		{"pulse", []string{"pulse"}, map[string]string{
+			"pactl list short sinks": "0\talsa_output.platform-bcm2835_audio.analog-stereo\tmodule-alsa-card.c\ts16le 2ch 44100Hz\tSUSPENDED\n" +
+				"1\tbluez_sink.AA_BB_CC_DD_EE_FF.a2dp_sink\tmodule-bluez5-device.c\ts16le 2ch 44100Hz\tRUNNING\n"}, true},
+		{"pipewire", []string{"pulse"}, map[string]string{
+			"pactl list short sinks": "45\tbluez_output.AA_BB_CC_DD_EE_FF.1\tPipeWire\ts16le 2ch 48000Hz\tIDLE\n"}, true},
+		{"pulse without speaker", []string{"pulse"}, map[string]string{
+			"pactl list short sinks": "0\talsa_output.platform-bcm2835_audio.analog-stereo\tmodule-alsa-card.c\n"}, false},
+		{"bluealsa-cli", []string{"bluealsa"}, map[string]string{
+			"bluealsa-cli list-pcms": "/org/bluealsa/hci0/dev_AA_BB_CC_DD_EE_FF/a2dpsrc/sink\n"}, true},
+		{"bluealsa-aplay", []string{"bluealsa"}, map[string]string{
+			"bluealsa-aplay -L": "bluealsa:SRV=org.bluealsa,DEV=AA:BB:CC:DD:EE:FF,PROFILE=a2dp\n    JBL Flip 4, trusted audio-card, playback\n"}, true},
+		{"bluealsa without speaker", []string{"bluealsa"}, map[string]string{"bluealsa-cli list-pcms": ""}, false},
+		{"second backend", []string{"pulse", "bluealsa"}, map[string]string{"pactl list short sinks": "",
+			"bluealsa-cli list-pcms": "/org/bluealsa/hci0/dev_AA_BB_CC_DD_EE_FF/a2dpsrc/sink\n"}, true},
+		{"no backend usable", []string{"pulse", "bluealsa"}, map[string]string{}, true},
+		{"no backend configured", nil, map[string]string{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewAudioChecker(fakeRunner(tt.outputs), tt.backends...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := c.Available(addr); got != tt.want || err != nil {
+				t.Errorf("Available() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := NewAudioChecker(nil, "jack"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+func TestAudioCheckerFailure(t *testing.T) {
+	outputs := map[string]string{"pactl list short sinks": "1\tbluez_sink.AA_BB_CC_DD_EE_FF.a2dp_sink\n"}
+	c, err := NewAudioChecker(fakeRunner(outputs), "pulse", "bluealsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Available("AA:BB:CC:DD:EE:FF"); !ok || err != nil {
+		t.Fatalf("got %v, %v", ok, err)
+	}
+	// pactl has answered before, so a failure makes the state unknown; bluealsa is still ignored
+	delete(outputs, "pactl list short sinks")
+	if _, err := c.Available("AA:BB:CC:DD:EE:FF"); err == nil {
+		t.Error("expected an error for the failing backend")
+	}
+}
+
+func TestAudioConnected(t *testing.T) {
+	devices := []Device{{Address: "A", Connected: true}, {Address: "B", Connected: true}, {Address: "C"}}
+	available := map[string]bool{"B": true, "C": true}
+	detector := AudioDetectorFunc(func(address string) (bool, error) { return available[address], nil })
+	addr, ok, err := AudioConnected(devices, detector)
+	if !ok || addr != "B" || err != nil {
+		t.Errorf("got %s, %v, %v", addr, ok, err)
+	}
+	available["B"] = false
+	if _, ok, _ = AudioConnected(devices, detector); ok {
+		t.Error("no device with audio expected")
+	}
+	failing := AudioDetectorFunc(func(string) (bool, error) { return false, errors.New("pactl failed") })
+	if _, _, err = AudioConnected(devices, failing); err == nil {
+		t.Error("expected the error of the detector")
+	}
+}
+
+func TestState(t *testing.T) {
+	s := NewState([]Device{{Address: "AA:BB:CC:DD:EE:FF"}, {Address: "11:22:33:44:55:66", Connected: true}})
+	if !s.Update(Event{Address: "aa:bb:cc:dd:ee:ff", Connected: true}) {
+		t.Error("the state should have changed")
+	}
+	if s.Update(Event{Address: "AA:BB:CC:DD:EE:FF", Connected: true}) {
+		t.Error("the state shouldn't have changed")
+	}
+	if s.Update(Event{Address: "00:00:00:00:00:01", Connected: true}) {
+		t.Error("unknown devices should be ignored")
+	}
+	if !s.Connected("AA:BB:CC:DD:EE:FF") || s.Connected("00:00:00:00:00:01") {
+		t.Error("wrong connection state")
+	}
+	if s.Add(Device{Address: "11:22:33:44:55:66"}) || !s.Add(Device{Address: "00:00:00:00:00:01"}) {
+		t.Error("only unknown devices should be added")
+	}
+	if n := len(s.Devices()); n != 3 {
+		t.Errorf("got %d devices, want 3", n)
+	}
+}
@@ -0,0 +1,96 @@
+package bluetooth
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// AudioSinkUUID is the service class of devices that play audio (A2DP sink), e.g. speakers and headphones
+const AudioSinkUUID = "0000110b-0000-1000-8000-00805f9b34fb"
+
+// Device is a paired bluetooth audio device
+type Device struct {
+	Address   string // e.g. "AA:BB:CC:DD:EE:FF"
+	Name      string
+	Connected bool
+}
+
+// Event reports that a device was connected or disconnected
+type Event struct {
+	Address   string
+	Connected bool
+}
+
+// Manager is the interface to the bluetooth stack
+type Manager interface {
+	// Devices returns the paired audio sinks
+	Devices() ([]Device, error)
+	// Connect connects the device with the given address
+	Connect(address string) error
+	// Discover scans for the given time and returns the audio sinks found that aren't paired yet
+	Discover(duration time.Duration) ([]Device, error)
+	// Pair pairs, trusts and connects the device with the given address
+	Pair(address string) error
+	// Events returns the channel with the connection changes
+	Events() <-chan Event
+	// Close stops listening and closes the event channel
+	Close()
+}
+
+// returns true if one of the uuids is the audio sink
+func hasAudioSink(uuids []string) bool {
+	for _, u := range uuids {
+		if strings.EqualFold(u, AudioSinkUUID) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortDevices(devices []Device) {
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+}
+
+// Prioritize sorts the devices by the priority list, which contains addresses or names. Devices that aren't
+// listed keep their order and follow the listed ones.
+func Prioritize(devices []Device, priority []string) []Device {
+	rank := func(d Device) int {
+		for i, p := range priority {
+			if strings.EqualFold(p, d.Address) || strings.EqualFold(p, d.Name) {
+				return i
+			}
+		}
+		return len(priority)
+	}
+	result := append([]Device(nil), devices...)
+	sort.SliceStable(result, func(i, j int) bool { return rank(result[i]) < rank(result[j]) })
+	return result
+}
+
+// Backoff returns increasing delays between connection attempts
+type Backoff struct {
+	min, max time.Duration
+	next     time.Duration
+}
+
+/**
+  Returns a backoff that doubles the delay from min up to max
+*/
+func NewBackoff(min, max time.Duration) *Backoff {
+	return &Backoff{min: min, max: max, next: min}
+}
+
+// Next returns the delay until the next attempt
+func (b *Backoff) Next() time.Duration {
+	d := b.next
+	if b.next *= 2; b.next > b.max {
+		b.next = b.max
+	}
+	return d
+}
+
+// Reset starts again with the minimum delay
+func (b *Backoff) Reset() {
+	b.next = b.min
+}
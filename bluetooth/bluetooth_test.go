@@ -0,0 +1,264 @@
+package bluetooth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const speakerInfo = `Device AA:BB:CC:DD:EE:FF (public)
+	Name: JBL Flip 4
+	Alias: Kitchen
+	Class: 0x00240414
+	Paired: yes
+	Trusted: yes
+	Connected: %s
+	UUID: Audio Sink                (0000110b-0000-1000-8000-00805f9b34fb)
+	UUID: A/V Remote Control        (0000110e-0000-1000-8000-00805f9b34fb)
+`
+
+const newSpeakerInfo = `Device 77:88:99:AA:BB:CC (public)
+	Name: Boombox
+	Paired: no
+	Connected: no
+	UUID: Audio Sink                (0000110b-0000-1000-8000-00805f9b34fb)
+`
+
+const phoneInfo = `Device 11:22:33:44:55:66 (public)
+	Name: Phone
+	Paired: yes
+	Connected: no
+	UUID: Audio Source              (0000110a-0000-1000-8000-00805f9b34fb)
+`
+
+// fakeBluetoothctl answers like bluetoothctl
+type fakeBluetoothctl struct {
+	mutex     sync.Mutex
+	connected string
+	scanned   bool
+	calls     []string
+}
+
+func (f *fakeBluetoothctl) run(name string, args ...string) ([]byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	switch strings.Join(args, " ") {
+	case "devices":
+		devices := "Device AA:BB:CC:DD:EE:FF JBL Flip 4\nDevice 11:22:33:44:55:66 Phone\n"
+		if f.scanned {
+			devices += "Device 77:88:99:AA:BB:CC Boombox\n"
+		}
+		return []byte(devices), nil
+	case "--timeout 10 scan on":
+		f.scanned = true
+		return []byte("Discovery started"), nil
+	case "info 77:88:99:AA:BB:CC":
+		return []byte(newSpeakerInfo), nil
+	case "pair 77:88:99:AA:BB:CC", "trust 77:88:99:AA:BB:CC", "connect 77:88:99:AA:BB:CC":
+		return []byte("ok"), nil
+	case "info AA:BB:CC:DD:EE:FF":
+		return []byte(fmt.Sprintf(speakerInfo, f.connected)), nil
+	case "info 11:22:33:44:55:66":
+		return []byte(phoneInfo), nil
+	case "connect AA:BB:CC:DD:EE:FF":
+		f.connected = "yes"
+		return []byte("Connection successful"), nil
+	}
+	return nil, fmt.Errorf("exit status 1")
+}
+
+func TestCtlDevices(t *testing.T) {
+	ctl := &fakeBluetoothctl{connected: "no"}
+	m := NewCtl(ctl.run, time.Hour)
+	defer m.Close()
+	devices, err := m.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Device{Address: "AA:BB:CC:DD:EE:FF", Name: "Kitchen"}
+	if len(devices) != 1 || devices[0] != want {
+		t.Errorf("got %+v, want %+v", devices, want)
+	}
+	if err = m.Connect("11:22:33:44:55:66"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestCtlEvents(t *testing.T) {
+	ctl := &fakeBluetoothctl{connected: "no"}
+	m := NewCtl(ctl.run, 5*time.Millisecond)
+	defer m.Close()
+	time.Sleep(20 * time.Millisecond)
+	if err := m.Connect("AA:BB:CC:DD:EE:FF"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-m.Events():
+		if e != (Event{Address: "AA:BB:CC:DD:EE:FF", Connected: true}) {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Error("no event")
+	}
+}
+
+func TestAudioSinks(t *testing.T) {
+	objects := managedObjects{
+		"/org/bluez/hci0": {"org.bluez.Adapter1": {"Address": dbus.MakeVariant("B8:27:EB:00:00:01")}},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF": {deviceInterface: {
+			"Address":   dbus.MakeVariant("AA:BB:CC:DD:EE:FF"),
+			"Alias":     dbus.MakeVariant("Kitchen"),
+			"Paired":    dbus.MakeVariant(true),
+			"Connected": dbus.MakeVariant(true),
+			"UUIDs":     dbus.MakeVariant([]string{"0000110E-0000-1000-8000-00805F9B34FB", "0000110B-0000-1000-8000-00805F9B34FB"}),
+		}},
+		"/org/bluez/hci0/dev_11_22_33_44_55_66": {deviceInterface: {
+			"Address": dbus.MakeVariant("11:22:33:44:55:66"),
+			"Paired":  dbus.MakeVariant(true),
+			"UUIDs":   dbus.MakeVariant([]string{"0000110a-0000-1000-8000-00805f9b34fb"}),
+		}},
+		"/org/bluez/hci0/dev_77_88_99_AA_BB_CC": {deviceInterface: {
+			"Address": dbus.MakeVariant("77:88:99:AA:BB:CC"),
+			"Paired":  dbus.MakeVariant(false),
+			"UUIDs":   dbus.MakeVariant([]string{AudioSinkUUID}),
+		}},
+	}
+	devices := audioSinks(objects)
+	want := Device{Address: "AA:BB:CC:DD:EE:FF", Name: "Kitchen", Connected: true}
+	if len(devices) != 1 || devices[0] != want {
+		t.Errorf("got %+v, want %+v", devices, want)
+	}
+}
+
+func TestEventFromSignal(t *testing.T) {
+	sig := &dbus.Signal{
+		Path: "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF",
+		Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+		Body: []interface{}{deviceInterface, map[string]dbus.Variant{"Connected": dbus.MakeVariant(false)}, []string{}},
+	}
+	e, ok := eventFromSignal(sig)
+	if !ok || e != (Event{Address: "AA:BB:CC:DD:EE:FF"}) {
+		t.Errorf("got %+v, %v", e, ok)
+	}
+	sig.Body[1] = map[string]dbus.Variant{"RSSI": dbus.MakeVariant(int16(-60))}
+	if _, ok = eventFromSignal(sig); ok {
+		t.Error("RSSI isn't a connection change")
+	}
+	sig.Body[0] = "org.bluez.MediaControl1"
+	if _, ok = eventFromSignal(sig); ok {
+		t.Error("wrong interface")
+	}
+}
+
+func TestFake(t *testing.T) {
+	f := NewFake(Device{Address: "A"}, Device{Address: "B"})
+	f.FailConnect("A")
+	if err := f.Connect("A"); err == nil {
+		t.Error("expected an error")
+	}
+	if err := f.Connect("B"); err != nil {
+		t.Error(err)
+	}
+	if e := <-f.Events(); e != (Event{Address: "B", Connected: true}) {
+		t.Errorf("unexpected event %+v", e)
+	}
+	devices, _ := f.Devices()
+	if devices[0].Connected || !devices[1].Connected {
+		t.Errorf("unexpected devices %+v", devices)
+	}
+	if c := f.Connects(); len(c) != 2 {
+		t.Errorf("unexpected connects %v", c)
+	}
+	f.AddCandidate(Device{Address: "C"})
+	if found, _ := f.Discover(time.Second); len(found) != 1 {
+		t.Errorf("unexpected candidates %+v", found)
+	}
+	if err := f.Pair("C"); err != nil {
+		t.Error(err)
+	}
+	if devices, _ = f.Devices(); len(devices) != 3 || !devices[2].Connected {
+		t.Errorf("unexpected devices %+v", devices)
+	}
+}
+
+func TestCtlPairing(t *testing.T) {
+	ctl := &fakeBluetoothctl{connected: "no"}
+	m := NewCtl(ctl.run, time.Hour)
+	defer m.Close()
+	found, err := m.Discover(10 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0] != (Device{Address: "77:88:99:AA:BB:CC", Name: "Boombox"}) {
+		t.Errorf("unexpected candidates %+v", found)
+	}
+	if devices, _ := m.Devices(); len(devices) != 1 {
+		t.Errorf("unpaired devices listed: %+v", devices)
+	}
+	if err = m.Pair("77:88:99:AA:BB:CC"); err != nil {
+		t.Error(err)
+	}
+	ctl.mutex.Lock()
+	calls := strings.Join(ctl.calls[len(ctl.calls)-3:], ", ")
+	ctl.mutex.Unlock()
+	if want := "bluetoothctl pair 77:88:99:AA:BB:CC, bluetoothctl trust 77:88:99:AA:BB:CC, bluetoothctl connect 77:88:99:AA:BB:CC"; calls != want {
+		t.Errorf("got calls %s, want %s", calls, want)
+	}
+	if err = m.Pair("11:22:33:44:55:66"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	objects := managedObjects{
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF": {deviceInterface: {
+			"Address": dbus.MakeVariant("AA:BB:CC:DD:EE:FF"),
+			"Paired":  dbus.MakeVariant(true),
+			"UUIDs":   dbus.MakeVariant([]string{AudioSinkUUID}),
+		}},
+		"/org/bluez/hci0/dev_77_88_99_AA_BB_CC": {deviceInterface: {
+			"Address": dbus.MakeVariant("77:88:99:AA:BB:CC"),
+			"Alias":   dbus.MakeVariant("Boombox"),
+			"Paired":  dbus.MakeVariant(false),
+			"UUIDs":   dbus.MakeVariant([]string{AudioSinkUUID}),
+		}},
+	}
+	devices := candidates(objects)
+	if len(devices) != 1 || devices[0] != (Device{Address: "77:88:99:AA:BB:CC", Name: "Boombox"}) {
+		t.Errorf("unexpected candidates %+v", devices)
+	}
+}
+
+func TestPrioritize(t *testing.T) {
+	devices := []Device{{Address: "A", Name: "Kitchen"}, {Address: "B", Name: "Bath"}, {Address: "C", Name: "Garden"},
+		{Address: "D", Name: "Office"}}
+	got := Prioritize(devices, []string{"garden", "B", "unknown"})
+	want := []string{"C", "B", "A", "D"}
+	for i := range want {
+		if got[i].Address != want[i] {
+			t.Fatalf("got %+v, want %v", got, want)
+		}
+	}
+	if devices[0].Address != "A" {
+		t.Error("the devices must not be changed")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := NewBackoff(3*time.Second, 20*time.Second)
+	want := []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 20 * time.Second, 20 * time.Second}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Errorf("delay %d: got %s, want %s", i, got, w)
+		}
+	}
+	b.Reset()
+	if got := b.Next(); got != 3*time.Second {
+		t.Errorf("got %s after reset", got)
+	}
+}
@@ -0,0 +1,158 @@
+package bluetooth
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner runs a command and returns its output
+type Runner func(name string, args ...string) ([]byte, error)
+
+// ExecRunner runs the command with os/exec
+func ExecRunner(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// ctlManager uses the command line tool bluetoothctl. It's used when BlueZ isn't reachable via D-Bus.
+// The connection changes are detected by polling.
+type ctlManager struct {
+	run      Runner
+	interval time.Duration
+	events   chan Event
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+/**
+  Returns a manager that uses bluetoothctl and polls the connection state with the given interval
+*/
+func NewCtl(run Runner, interval time.Duration) Manager {
+	if run == nil {
+		run = ExecRunner
+	}
+	m := &ctlManager{run: run, interval: interval, events: make(chan Event, 16), done: make(chan struct{})}
+	m.wg.Add(1)
+	go m.poll()
+	return m
+}
+
+func (m *ctlManager) Devices() ([]Device, error) {
+	return m.list(true)
+}
+
+// returns the known audio sinks that are paired or not paired
+func (m *ctlManager) list(paired bool) ([]Device, error) {
+	out, err := m.run("bluetoothctl", "devices")
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, line := range strings.Split(string(out), "\n") {
+		// e.g. "Device AA:BB:CC:DD:EE:FF JBL Flip 4"
+		parts := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(parts) < 2 || parts[0] != "Device" {
+			continue
+		}
+		info, err := m.run("bluetoothctl", "info", parts[1])
+		if err != nil {
+			// a partial list would report the missing devices as disconnected
+			return nil, err
+		}
+		if d, p, sink := parseInfo(parts[1], string(info)); sink && p == paired {
+			devices = append(devices, d)
+		}
+	}
+	sortDevices(devices)
+	return devices, nil
+}
+
+func (m *ctlManager) Connect(address string) error {
+	_, err := m.run("bluetoothctl", "connect", address)
+	return err
+}
+
+func (m *ctlManager) Discover(duration time.Duration) ([]Device, error) {
+	secs := strconv.Itoa(int(duration.Seconds() + 0.5))
+	if _, err := m.run("bluetoothctl", "--timeout", secs, "scan", "on"); err != nil {
+		return nil, err
+	}
+	return m.list(false)
+}
+
+func (m *ctlManager) Pair(address string) error {
+	for _, cmd := range []string{"pair", "trust", "connect"} {
+		if _, err := m.run("bluetoothctl", cmd, address); err != nil {
+			return fmt.Errorf("bluetoothctl %s %s: %w", cmd, address, err)
+		}
+	}
+	return nil
+}
+
+func (m *ctlManager) Events() <-chan Event {
+	return m.events
+}
+
+func (m *ctlManager) Close() {
+	close(m.done)
+	m.wg.Wait()
+	close(m.events)
+}
+
+// compares the connection state of the devices with the last state
+func (m *ctlManager) poll() {
+	defer m.wg.Done()
+	var last map[string]bool
+	for {
+		if devices, err := m.Devices(); err == nil {
+			state := map[string]bool{}
+			for _, d := range devices {
+				state[d.Address] = d.Connected
+				if last != nil && last[d.Address] != d.Connected {
+					select {
+					case m.events <- Event{Address: d.Address, Connected: d.Connected}:
+					case <-m.done:
+						return
+					}
+				}
+			}
+			last = state
+		}
+		select {
+		case <-m.done:
+			return
+		case <-time.After(m.interval):
+		}
+	}
+}
+
+// parses the output of 'bluetoothctl info' and returns the device and whether it's paired and an audio sink
+func parseInfo(address, info string) (d Device, paired bool, sink bool) {
+	d.Address = address
+	for _, line := range strings.Split(info, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		switch kv[0] {
+		case "Alias":
+			d.Name = value
+		case "Name":
+			if d.Name == "" {
+				d.Name = value
+			}
+		case "Connected":
+			d.Connected = value == "yes"
+		case "Paired":
+			paired = value == "yes"
+		case "UUID":
+			// e.g. "UUID: Audio Sink                (0000110b-0000-1000-8000-00805f9b34fb)"
+			sink = sink || strings.Contains(value, "Audio Sink") || strings.Contains(value, AudioSinkUUID)
+		}
+	}
+	return d, paired, sink
+}
@@ -0,0 +1,249 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	bluezService        = "org.bluez"
+	adapterInterface    = "org.bluez.Adapter1"
+	deviceInterface     = "org.bluez.Device1"
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+	connectTimeout      = 20 * time.Second
+	pairTimeout         = 60 * time.Second
+)
+
+// dbusManager talks to BlueZ via the D-Bus system bus
+type dbusManager struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+	events  chan Event
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// the objects of BlueZ: path -> interface -> property -> value
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+/**
+  Connects to BlueZ via the D-Bus system bus and subscribes to the connection changes of the devices
+*/
+func NewDBus() (Manager, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	var owner string
+	if err = conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, bluezService).Store(&owner); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("bluez isn't running: %w", err)
+	}
+	err = conn.AddMatchSignal(dbus.WithMatchInterface(propertiesInterface), dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, deviceInterface))
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	m := &dbusManager{conn: conn, signals: make(chan *dbus.Signal, 16), events: make(chan Event, 16),
+		done: make(chan struct{})}
+	conn.Signal(m.signals)
+	m.wg.Add(1)
+	go m.run()
+	return m, nil
+}
+
+func (m *dbusManager) objects() (managedObjects, error) {
+	var objects managedObjects
+	err := m.conn.Object(bluezService, "/").Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).
+		Store(&objects)
+	return objects, err
+}
+
+func (m *dbusManager) Devices() ([]Device, error) {
+	objects, err := m.objects()
+	if err != nil {
+		return nil, err
+	}
+	return audioSinks(objects), nil
+}
+
+// returns the object path of the device with the given address
+func (m *dbusManager) devicePath(address string) (dbus.ObjectPath, error) {
+	objects, err := m.objects()
+	if err != nil {
+		return "", err
+	}
+	for path, ifaces := range objects {
+		if d, _, _ := deviceFromProperties(ifaces[deviceInterface]); d.Address == address {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("unknown device %s", address)
+}
+
+// calls a method of a device with a timeout
+func (m *dbusManager) callDevice(path dbus.ObjectPath, method string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.conn.Object(bluezService, path).CallWithContext(ctx, deviceInterface+"."+method, 0).Err
+}
+
+func (m *dbusManager) Connect(address string) error {
+	path, err := m.devicePath(address)
+	if err != nil {
+		return err
+	}
+	return m.callDevice(path, "Connect", connectTimeout)
+}
+
+func (m *dbusManager) Discover(duration time.Duration) ([]Device, error) {
+	objects, err := m.objects()
+	if err != nil {
+		return nil, err
+	}
+	var adapter dbus.ObjectPath
+	for path, ifaces := range objects {
+		if _, ok := ifaces[adapterInterface]; ok {
+			adapter = path
+			break
+		}
+	}
+	if adapter == "" {
+		return nil, fmt.Errorf("no bluetooth adapter found")
+	}
+	obj := m.conn.Object(bluezService, adapter)
+	// the filter is optional, older versions of BlueZ don't support it
+	_ = obj.Call(adapterInterface+".SetDiscoveryFilter", 0, map[string]interface{}{
+		"UUIDs": []string{AudioSinkUUID}, "Transport": "bredr"}).Err
+	if err = obj.Call(adapterInterface+".StartDiscovery", 0).Err; err != nil {
+		return nil, err
+	}
+	time.Sleep(duration)
+	_ = obj.Call(adapterInterface+".StopDiscovery", 0).Err
+	if objects, err = m.objects(); err != nil {
+		return nil, err
+	}
+	return candidates(objects), nil
+}
+
+func (m *dbusManager) Pair(address string) error {
+	path, err := m.devicePath(address)
+	if err != nil {
+		return err
+	}
+	if err = m.callDevice(path, "Pair", pairTimeout); err != nil {
+		var dbusErr dbus.Error
+		if !errors.As(err, &dbusErr) || dbusErr.Name != "org.bluez.Error.AlreadyExists" {
+			return err
+		}
+	}
+	err = m.conn.Object(bluezService, path).Call(propertiesInterface+".Set", 0, deviceInterface, "Trusted",
+		dbus.MakeVariant(true)).Err
+	if err != nil {
+		return err
+	}
+	return m.callDevice(path, "Connect", connectTimeout)
+}
+
+func (m *dbusManager) Events() <-chan Event {
+	return m.events
+}
+
+func (m *dbusManager) Close() {
+	close(m.done)
+	_ = m.conn.Close() // closes the signal channel
+	m.wg.Wait()
+	close(m.events)
+}
+
+// converts the changes of the property 'Connected' into events
+func (m *dbusManager) run() {
+	defer m.wg.Done()
+	for sig := range m.signals {
+		e, ok := eventFromSignal(sig)
+		if !ok {
+			continue
+		}
+		select {
+		case m.events <- e:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// returns the paired audio sinks of the BlueZ objects
+func audioSinks(objects managedObjects) []Device {
+	var devices []Device
+	for _, ifaces := range objects {
+		props, ok := ifaces[deviceInterface]
+		if !ok {
+			continue
+		}
+		if d, paired, sink := deviceFromProperties(props); paired && sink {
+			devices = append(devices, d)
+		}
+	}
+	sortDevices(devices)
+	return devices
+}
+
+// returns the audio sinks of the BlueZ objects that aren't paired yet
+func candidates(objects managedObjects) []Device {
+	var devices []Device
+	for _, ifaces := range objects {
+		props, ok := ifaces[deviceInterface]
+		if !ok {
+			continue
+		}
+		if d, paired, sink := deviceFromProperties(props); !paired && sink {
+			devices = append(devices, d)
+		}
+	}
+	sortDevices(devices)
+	return devices
+}
+
+// returns the device of the properties of the interface org.bluez.Device1
+func deviceFromProperties(props map[string]dbus.Variant) (d Device, paired bool, sink bool) {
+	d.Address, _ = props["Address"].Value().(string)
+	d.Name, _ = props["Alias"].Value().(string)
+	d.Connected, _ = props["Connected"].Value().(bool)
+	paired, _ = props["Paired"].Value().(bool)
+	uuids, _ := props["UUIDs"].Value().([]string)
+	return d, paired, hasAudioSink(uuids)
+}
+
+// returns the event of a PropertiesChanged signal, if the property 'Connected' of a device has changed
+func eventFromSignal(sig *dbus.Signal) (Event, bool) {
+	if sig.Name != propertiesInterface+".PropertiesChanged" || len(sig.Body) < 2 {
+		return Event{}, false
+	}
+	if iface, _ := sig.Body[0].(string); iface != deviceInterface {
+		return Event{}, false
+	}
+	changed, _ := sig.Body[1].(map[string]dbus.Variant)
+	v, ok := changed["Connected"]
+	if !ok {
+		return Event{}, false
+	}
+	connected, _ := v.Value().(bool)
+	return Event{Address: addressFromPath(sig.Path), Connected: connected}, true
+}
+
+// converts the object path of a device (e.g. /org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF) into its address
+func addressFromPath(path dbus.ObjectPath) string {
+	p := string(path)
+	idx := strings.LastIndex(p, "/dev_")
+	if idx < 0 {
+		return ""
+	}
+	return strings.ReplaceAll(p[idx+5:], "_", ":")
+}
@@ -0,0 +1,107 @@
+package bluetooth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Fake is a Manager for tests. Connect succeeds for all devices, except for those passed to FailConnect.
+type Fake struct {
+	mutex      sync.Mutex
+	devices    []Device
+	candidates []Device // devices found by Discover
+	failing    map[string]bool
+	connects   []string
+	events     chan Event
+}
+
+/**
+  Returns a fake manager with the given paired devices
+*/
+func NewFake(devices ...Device) *Fake {
+	return &Fake{devices: devices, failing: map[string]bool{}, events: make(chan Event, 16)}
+}
+
+func (f *Fake) Devices() ([]Device, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]Device(nil), f.devices...), nil
+}
+
+func (f *Fake) Connect(address string) error {
+	f.mutex.Lock()
+	f.connects = append(f.connects, address)
+	failing := f.failing[address]
+	f.mutex.Unlock()
+	if failing {
+		return fmt.Errorf("connecting %s failed", address)
+	}
+	f.SetConnected(address, true)
+	return nil
+}
+
+func (f *Fake) Discover(duration time.Duration) ([]Device, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]Device(nil), f.candidates...), nil
+}
+
+func (f *Fake) Pair(address string) error {
+	f.mutex.Lock()
+	found := false
+	for i, d := range f.candidates {
+		if d.Address == address {
+			f.devices = append(f.devices, d)
+			f.candidates = append(f.candidates[:i], f.candidates[i+1:]...)
+			found = true
+			break
+		}
+	}
+	f.mutex.Unlock()
+	if !found {
+		return fmt.Errorf("unknown device %s", address)
+	}
+	return f.Connect(address)
+}
+
+// AddCandidate adds a device that is found by Discover
+func (f *Fake) AddCandidate(d Device) {
+	f.mutex.Lock()
+	f.candidates = append(f.candidates, d)
+	f.mutex.Unlock()
+}
+
+func (f *Fake) Events() <-chan Event {
+	return f.events
+}
+
+func (f *Fake) Close() {
+	close(f.events)
+}
+
+// FailConnect lets the connection attempts to the device fail
+func (f *Fake) FailConnect(address string) {
+	f.mutex.Lock()
+	f.failing[address] = true
+	f.mutex.Unlock()
+}
+
+// Connects returns the addresses of all connection attempts
+func (f *Fake) Connects() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.connects...)
+}
+
+// SetConnected changes the connection state of a device and sends the event
+func (f *Fake) SetConnected(address string, connected bool) {
+	f.mutex.Lock()
+	for i := range f.devices {
+		if f.devices[i].Address == address {
+			f.devices[i].Connected = connected
+		}
+	}
+	f.mutex.Unlock()
+	f.events <- Event{Address: address, Connected: connected}
+}
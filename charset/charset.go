@@ -0,0 +1,32 @@
+package charset
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Lookup returns the encoding for the given charset name (e.g. "latin1", "windows-1252", "iso-8859-2", "cp1251",
+// "koi8-r"). The names follow the WHATWG encoding standard, so "latin1" is handled as windows-1252.
+func Lookup(name string) (encoding.Encoding, error) {
+	return htmlindex.Get(strings.TrimSpace(name))
+}
+
+// Decode returns the text unchanged when it's valid UTF-8. Otherwise, the text is converted from the given
+// fallback charset to UTF-8. When the charset is unknown, the invalid bytes are removed.
+func Decode(text string, fallback string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+	enc, err := Lookup(fallback)
+	if err != nil {
+		return strings.ToValidUTF8(text, "")
+	}
+	decoded, err := enc.NewDecoder().String(text)
+	if err != nil {
+		return strings.ToValidUTF8(text, "")
+	}
+	return decoded
+}
@@ -0,0 +1,41 @@
+package charset
+
+import (
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		charset string
+		in      string
+		want    string
+	}{
+		{"latin1", "Caf\xe9 del Mar", "Café del Mar"},
+		{"iso-8859-1", "Die \xc4rzte - M\xe4nner sind Schweine", "Die Ärzte - Männer sind Schweine"},
+		{"latin1", "Sigur R\xf3s - Hopp\xedpolla", "Sigur Rós - Hoppípolla"},
+		{"windows-1252", "\x93Hello\x94 \x96 Caf\xe9\x85", "“Hello” – Café…"},
+		{"iso-8859-2", "Kraj \xb3\xf3d\xbc", "Kraj łódź"},
+		{"cp1251", "\xcf\xf0\xe8\xe2\xe5\xf2", "Привет"},
+		{"koi8-r", "\xf0\xd2\xc9\xd7\xc5\xd4", "Привет"},
+		{"latin1", "Café del Mar", "Café del Mar"}, // valid UTF-8 stays untouched
+		{"latin1", "abc", "abc"},
+		{"unknown", "Caf\xe9", "Caf"},
+	}
+	for _, tt := range tests {
+		got := Decode(tt.in, tt.charset)
+		if got != tt.want {
+			t.Errorf("Decode(%q, %q) = %q, want %q", tt.in, tt.charset, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"latin1", "ISO-8859-15", "windows-1250", "cp1252", " koi8-r "} {
+		if _, err := Lookup(name); err != nil {
+			t.Error(name, err)
+		}
+	}
+	if _, err := Lookup("klingon"); err == nil {
+		t.Error("klingon should be unknown")
+	}
+}
@@ -0,0 +1,156 @@
+package cleanup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Action defines what happens, when the pattern of a rule matches
+type Action int
+
+const (
+	Replace Action = iota // replaces all matches with the replacement
+	Drop                  // drops the whole title
+)
+
+// Rule is a single cleanup rule. An empty scope means, that the rule is used for all stations.
+type Rule struct {
+	Scope       string
+	Action      Action
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+var multiSpace = regexp.MustCompile(`\s{2,}`)
+
+/**
+  Returns the default rules. They remove remix and edit names in round brackets like " (CDM Radio Edit)".
+*/
+func Default() []Rule {
+	return []Rule{
+		{
+			Action:  Replace,
+			Pattern: regexp.MustCompile(`(?i)\s*\.?\([^()]*(edit|mix|cdm|cut|rmx|cover)[^()]*\)`),
+		},
+	}
+}
+
+// Load reads the rules from the given file. See Parse for the format of the file.
+func Load(fileName string) ([]Rule, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	return Parse(f)
+}
+
+/**
+  Parses the rules. Empty lines and lines starting with '#' are ignored. The rules are used in the given order.
+  A line '[*]' starts the global rules (the default), a line '[Station Name]' starts the rules that are only
+  used for that station. The default rules are placed in front of the rules, unless the line 'nodefaults' is found.
+
+    replace <regex> => <replacement>
+    replace <regex>
+    drop <regex>
+*/
+func Parse(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scope := ""
+	defaults := true
+	scanner := bufio.NewScanner(r)
+	nr := 0
+	for scanner.Scan() {
+		nr++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "nodefaults" {
+			defaults = false
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			scope = strings.TrimSpace(line[1 : len(line)-1])
+			if scope == "*" {
+				scope = ""
+			}
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: missing pattern", nr)
+		}
+		rule := Rule{Scope: scope}
+		pattern := strings.TrimSpace(parts[1])
+		switch parts[0] {
+		case "replace":
+			rule.Action = Replace
+			if idx := strings.LastIndex(pattern, "=>"); idx >= 0 {
+				rule.Replacement = unquote(strings.TrimSpace(pattern[idx+2:]))
+				pattern = strings.TrimSpace(pattern[:idx])
+			}
+		case "drop":
+			rule.Action = Drop
+		default:
+			return nil, fmt.Errorf("line %d: unknown action %s", nr, parts[0])
+		}
+		var err error
+		rule.Pattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", nr, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if defaults {
+		rules = append(Default(), rules...)
+	}
+	return rules, nil
+}
+
+// Apply uses all global rules and the rules of the given stations on the title. A station matches, when the
+// scope of the rule is equal to one of the names (case-insensitive). An empty string is returned, when the title
+// was dropped.
+func Apply(rules []Rule, title string, stationNames ...string) string {
+	for _, rule := range rules {
+		if !inScope(rule.Scope, stationNames) {
+			continue
+		}
+		if !rule.Pattern.MatchString(title) {
+			continue
+		}
+		if rule.Action == Drop {
+			return ""
+		}
+		title = rule.Pattern.ReplaceAllString(title, rule.Replacement)
+		title = strings.TrimSpace(multiSpace.ReplaceAllString(title, " "))
+	}
+	return title
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func inScope(scope string, stationNames []string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, name := range stationNames {
+		if strings.EqualFold(scope, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
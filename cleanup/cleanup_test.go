@@ -0,0 +1,98 @@
+package cleanup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"CdmEdit", "Memory Pages (CDM Radio Edit)", "Memory Pages"},
+		{"Trailing", "Memory Pages (CDM Radio Edit) and more", "Memory Pages and more"},
+		{"Mix", "DANCING SEAHORSES FEAT. MARC HARTMAN (ORIGINAL MIX)", "DANCING SEAHORSES FEAT. MARC HARTMAN"},
+		{"Cut", "Under The Radar (Dragonflight Cut)", "Under The Radar"},
+		{"NoNoise", "After dark (On The Road Again)", "After dark (On The Road Again)"},
+		{"Remix", "Tide (Electro RMX)", "Tide"},
+		{"Cover", "Slave to the rhythm .(cover)", "Slave to the rhythm"},
+		{"SecondGroup", "After dark (On The Road Again) (Radio Edit)", "After dark (On The Road Again)"},
+		{"SquareBrackets", "Tide [Electro RMX]", "Tide [Electro RMX]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Apply(Default(), tt.title)
+			if res != tt.want {
+				t.Errorf("got %q, want %q", res, tt.want)
+			}
+		})
+	}
+}
+
+const testRules = `
+# global rules
+[*]
+replace (?i)\s*\[[^\[\]]*(edit|mix|rmx)[^\[\]]*\]
+replace (?i)\b(feat\.?|ft\.|featuring)\s+ => "feat. "
+drop ^\s*$
+
+[NDR2]
+drop \*\*\* Werbung \*\*\*
+replace ^NDR 2 - =>
+`
+
+func TestParse(t *testing.T) {
+	rules, err := Parse(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(Default())+5 {
+		t.Fatal("number of rules", len(rules))
+	}
+	tests := []struct {
+		name    string
+		title   string
+		station string
+		want    string
+	}{
+		{"Default", "Tide (Electro RMX)", "", "Tide"},
+		{"SquareBrackets", "Tide [Electro RMX] and more", "", "Tide and more"},
+		{"SquareBracketsNoNoise", "Tide [Live]", "", "Tide [Live]"},
+		{"Feat", "Dancing Seahorses Feat. Marc Hartman", "", "Dancing Seahorses feat. Marc Hartman"},
+		{"Ft", "Dancing Seahorses ft. Marc Hartman", "", "Dancing Seahorses feat. Marc Hartman"},
+		{"Featuring", "Dancing Seahorses featuring Marc Hartman", "", "Dancing Seahorses feat. Marc Hartman"},
+		{"NoFeat", "Defeat - The Song", "", "Defeat - The Song"},
+		{"DropStation", "*** Werbung ***", "ndr2", ""},
+		{"DropOtherStation", "*** Werbung ***", "NJoy", "*** Werbung ***"},
+		{"ReplaceStation", "NDR 2 - Sting - Fragile", "NDR2", "Sting - Fragile"},
+		{"DropEmpty", "   ", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Apply(rules, tt.title, tt.station)
+			if res != tt.want {
+				t.Errorf("got %q, want %q", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoDefaults(t *testing.T) {
+	rules, err := Parse(strings.NewReader("nodefaults\ndrop ^x$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Action != Drop {
+		t.Error("nodefaults", rules)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{"replace", "remove abc", "drop ([a-z]"} {
+		if _, err := Parse(strings.NewReader(content)); err == nil {
+			t.Error("error expected for", content)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the sections of the configuration file '~/.piradio/config'
+type Config struct {
+	sections map[string]*Section
+}
+
+// Section holds the entries of one section in the order of the file
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+// Entry is a single 'key = value' line
+type Entry struct {
+	Key   string
+	Value string
+}
+
+/**
+  Returns an empty configuration. All getters return their default values.
+*/
+func New() *Config {
+	return &Config{sections: map[string]*Section{}}
+}
+
+// Load reads the configuration from the given file
+func Load(fileName string) (*Config, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	return Parse(f)
+}
+
+/**
+  Parses a configuration in INI style. Empty lines and lines starting with '#' are ignored. Section names and
+  keys are case-insensitive.
+
+    [section]
+    key = value
+*/
+func Parse(r io.Reader) (*Config, error) {
+	c := New()
+	var section *Section
+	scanner := bufio.NewScanner(r)
+	nr := 0
+	for scanner.Scan() {
+		nr++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			section = c.sections[name]
+			if section == nil {
+				section = &Section{Name: name}
+				c.sections[name] = section
+			}
+			continue
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: entry outside of a section", nr)
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: missing '='", nr)
+		}
+		section.Entries = append(section.Entries, Entry{
+			Key:   strings.ToLower(strings.TrimSpace(kv[0])),
+			Value: strings.TrimSpace(kv[1]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Section returns the section with the given name. It is never nil, missing sections are empty.
+func (c *Config) Section(name string) *Section {
+	if s := c.sections[strings.ToLower(name)]; s != nil {
+		return s
+	}
+	return &Section{Name: strings.ToLower(name)}
+}
+
+// Lookup returns the value of the last entry with the given key
+func (s *Section) Lookup(key string) (string, bool) {
+	key = strings.ToLower(key)
+	for i := len(s.Entries) - 1; i >= 0; i-- {
+		if s.Entries[i].Key == key {
+			return s.Entries[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// String returns the value of the given key or the default value
+func (s *Section) String(key string, def string) string {
+	if v, ok := s.Lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the value of the given key as integer or the default value
+func (s *Section) Int(key string, def int) int {
+	if v, ok := s.Lookup(key); ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// Bool returns the value of the given key as bool or the default value
+func (s *Section) Bool(key string, def bool) bool {
+	if v, ok := s.Lookup(key); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Duration returns the value of the given key as duration (e.g. "1.5s") or the default value
+func (s *Section) Duration(key string, def time.Duration) time.Duration {
+	if v, ok := s.Lookup(key); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// List returns the comma separated values of the given key. All entries with that key are combined.
+func (s *Section) List(key string) []string {
+	var list []string
+	key = strings.ToLower(key)
+	for _, e := range s.Entries {
+		if e.Key != key {
+			continue
+		}
+		for _, v := range strings.Split(e.Value, ",") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
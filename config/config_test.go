@@ -0,0 +1,56 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+# comment
+[TitleCase]
+acronyms = ABBA, DJ
+acronyms = AC/DC
+romanNumerals = false
+
+[buttons]
+next = GPIO5
+delay = 1.5s
+count = 3
+count = 4
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := c.Section("titlecase")
+	if l := tc.List("Acronyms"); strings.Join(l, "|") != "ABBA|DJ|AC/DC" {
+		t.Error("list", l)
+	}
+	if tc.Bool("romanNumerals", true) {
+		t.Error("bool")
+	}
+	b := c.Section("buttons")
+	if b.String("next", "") != "GPIO5" || b.String("prev", "GPIO6") != "GPIO6" {
+		t.Error("string")
+	}
+	if b.Duration("delay", 0) != 1500*time.Millisecond {
+		t.Error("duration")
+	}
+	if b.Int("count", 0) != 4 || b.Int("next", 7) != 7 {
+		t.Error("int")
+	}
+	if len(c.Section("missing").Entries) != 0 {
+		t.Error("missing section")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{"key = value", "[section]\nnovalue"} {
+		if _, err := Parse(strings.NewReader(content)); err == nil {
+			t.Error("error expected for", content)
+		}
+	}
+}
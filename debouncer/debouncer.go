@@ -1,23 +1,178 @@
 package debouncer
 
 import (
+	"sync"
 	"time"
 )
 
-type debounce struct {
-	wait  time.Duration
-	timer *time.Timer
+// Mode defines when the debounced function is called
+type Mode int
+
+const (
+	// Trailing calls the function after the calls have stopped for the wait time
+	Trailing Mode = iota
+	// Leading calls the function immediately and ignores further calls until they have stopped for the wait time
+	Leading
+	// Throttle calls the function immediately and then at most once per wait time with the last function
+	Throttle
+)
+
+// Timer is the part of time.Timer that is used by the debouncer
+type Timer interface {
+	Stop() bool
+}
+
+// Clock is the source of time, it can be replaced in tests
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Options configure the debouncer
+type Options struct {
+	Mode    Mode
+	MaxWait time.Duration // maximum time a burst of calls is delayed (Trailing) or suppressed (Leading); 0 for no limit
+	Clock   Clock         // defaults to the real clock
+}
+
+// Debouncer delays or suppresses calls of functions that are triggered in a burst. It's safe for concurrent use.
+type Debouncer struct {
+	wait    time.Duration
+	opts    Options
+	mutex   sync.Mutex
+	timer   Timer
+	gen     int       // incremented with every new timer, so that a stale timer does nothing
+	pending func()    // function that is called when the timer expires
+	start   time.Time // start of the current burst
+	active  bool      // a burst (or throttle interval) is running
 }
 
 /**
-  Returns a debouncer
+  Returns a debouncer with the given wait time
 */
-func New(wait time.Duration) func(f func()) {
-	d := &debounce{wait: wait}
-	return func(f func()) {
-		if d.timer != nil {
-			d.timer.Stop()
+func New(wait time.Duration, opts Options) *Debouncer {
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+	return &Debouncer{wait: wait, opts: opts}
+}
+
+// Trigger calls the function according to the mode of the debouncer. The last function triggered wins.
+func (d *Debouncer) Trigger(f func()) {
+	d.mutex.Lock()
+	now := d.opts.Clock.Now()
+	var run func()
+	switch d.opts.Mode {
+	case Leading:
+		if !d.active {
+			d.active = true
+			d.start = now
+			run = f
+		}
+		d.schedule(d.delay(now))
+	case Throttle:
+		if d.active {
+			d.pending = f
+		} else {
+			d.active = true
+			run = f
+			d.schedule(d.wait)
+		}
+	default:
+		if !d.active {
+			d.active = true
+			d.start = now
 		}
-		d.timer = time.AfterFunc(d.wait, f)
+		d.pending = f
+		d.schedule(d.delay(now))
+	}
+	d.mutex.Unlock()
+	if run != nil {
+		run()
+	}
+}
+
+// Cancel discards a pending call
+func (d *Debouncer) Cancel() {
+	d.mutex.Lock()
+	d.reset()
+	d.mutex.Unlock()
+}
+
+// Flush calls a pending function immediately. Nothing happens, when no call is pending.
+func (d *Debouncer) Flush() {
+	d.mutex.Lock()
+	f := d.pending
+	d.reset()
+	d.mutex.Unlock()
+	if f != nil {
+		f()
+	}
+}
+
+// returns the wait time, shortened if the burst would exceed the maximum wait time
+func (d *Debouncer) delay(now time.Time) time.Duration {
+	if d.opts.MaxWait <= 0 {
+		return d.wait
+	}
+	left := d.start.Add(d.opts.MaxWait).Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	if left < d.wait {
+		return left
+	}
+	return d.wait
+}
+
+// (re)starts the timer; must be called with the lock held
+func (d *Debouncer) schedule(delay time.Duration) {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.gen++
+	gen := d.gen
+	d.timer = d.opts.Clock.AfterFunc(delay, func() { d.expire(gen) })
+}
+
+func (d *Debouncer) expire(gen int) {
+	d.mutex.Lock()
+	if gen != d.gen {
+		d.mutex.Unlock()
+		return
+	}
+	f := d.pending
+	d.pending = nil
+	d.timer = nil
+	d.active = false
+	if f != nil && d.opts.Mode == Throttle {
+		// the next interval starts with this call
+		d.active = true
+		d.schedule(d.wait)
+	}
+	d.mutex.Unlock()
+	if f != nil {
+		f()
+	}
+}
+
+// stops the timer and ends the burst; must be called with the lock held
+func (d *Debouncer) reset() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
 	}
+	d.gen++
+	d.pending = nil
+	d.active = false
 }
@@ -1,31 +1,225 @@
 package debouncer
 
 import (
+	"sort"
+	"sync"
 	"testing"
 	"time"
 )
 
-var (
-	counter int
-)
+// fakeClock fires the timers only when the time is advanced
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *fakeClock
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+// advances the time and fires the expired timers in order
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			break
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.stopped {
+			continue
+		}
+		t.stopped = true
+		c.now = t.at
+		c.mutex.Unlock()
+		t.f()
+		c.mutex.Lock()
+	}
+	c.now = end
+	c.mutex.Unlock()
+}
+
+// counts the calls
+type counter struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (c *counter) fn(name string) func() {
+	return func() {
+		c.mutex.Lock()
+		c.calls = append(c.calls, name)
+		c.mutex.Unlock()
+	}
+}
 
-func TestDebouncer(t *testing.T) {
+func (c *counter) expect(t *testing.T, want ...string) {
+	t.Helper()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.calls) != len(want) {
+		t.Fatalf("got calls %v, want %v", c.calls, want)
+	}
+	for i := range want {
+		if c.calls[i] != want[i] {
+			t.Fatalf("got calls %v, want %v", c.calls, want)
+		}
+	}
+}
+
+func TestTrailing(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{Clock: clock})
+
+	d.Trigger(c.fn("a"))
+	d.Trigger(c.fn("b"))
+	clock.Advance(40 * time.Millisecond)
+	d.Trigger(c.fn("c"))
+	clock.Advance(40 * time.Millisecond)
+	c.expect(t)
+	clock.Advance(10 * time.Millisecond)
+	c.expect(t, "c")
+	clock.Advance(time.Second)
+	c.expect(t, "c")
+}
+
+func TestTrailingMaxWait(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{MaxWait: 120 * time.Millisecond, Clock: clock})
+
+	for i := 0; i < 5; i++ {
+		d.Trigger(c.fn("a"))
+		clock.Advance(30 * time.Millisecond)
+	}
+	// the burst started at 0, so the call is made at 120ms
+	c.expect(t, "a")
+	d.Trigger(c.fn("b"))
+	clock.Advance(50 * time.Millisecond)
+	c.expect(t, "a", "b")
+}
 
-	debTester := func() {
-		counter++
+func TestLeading(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{Mode: Leading, Clock: clock})
+
+	d.Trigger(c.fn("a"))
+	c.expect(t, "a")
+	clock.Advance(40 * time.Millisecond)
+	d.Trigger(c.fn("b"))
+	clock.Advance(40 * time.Millisecond)
+	d.Trigger(c.fn("c"))
+	clock.Advance(50 * time.Millisecond)
+	c.expect(t, "a")
+	d.Trigger(c.fn("d"))
+	c.expect(t, "a", "d")
+}
+
+func TestLeadingMaxWait(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{Mode: Leading, MaxWait: 100 * time.Millisecond, Clock: clock})
+
+	for i := 0; i < 5; i++ {
+		d.Trigger(c.fn("x"))
+		clock.Advance(30 * time.Millisecond)
 	}
+	// calls at 0 and 120ms, the burst ended at 100ms
+	c.expect(t, "x", "x")
+}
 
-	debounced := New(50 * time.Millisecond)
+func TestThrottle(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{Mode: Throttle, Clock: clock})
 
-	counter = 0
+	d.Trigger(c.fn("a"))
+	c.expect(t, "a")
+	clock.Advance(10 * time.Millisecond)
+	d.Trigger(c.fn("b"))
+	d.Trigger(c.fn("c"))
+	clock.Advance(40 * time.Millisecond)
+	c.expect(t, "a", "c")
+	clock.Advance(20 * time.Millisecond)
+	d.Trigger(c.fn("d"))
+	c.expect(t, "a", "c")
+	clock.Advance(30 * time.Millisecond)
+	c.expect(t, "a", "c", "d")
+	clock.Advance(50 * time.Millisecond)
+	d.Trigger(c.fn("e"))
+	c.expect(t, "a", "c", "d", "e")
+}
+
+func TestCancelAndFlush(t *testing.T) {
+	clock := newFakeClock()
+	c := &counter{}
+	d := New(50*time.Millisecond, Options{Clock: clock})
 
-	debounced(debTester)
-	debounced(debTester)
-	debounced(debTester)
-	debounced(debTester)
+	d.Trigger(c.fn("a"))
+	d.Cancel()
+	clock.Advance(time.Second)
+	c.expect(t)
 
-	time.Sleep(55 * time.Millisecond)
-	if counter != 1 {
-		t.Error("Debouncer did't work", counter)
+	d.Trigger(c.fn("b"))
+	d.Flush()
+	c.expect(t, "b")
+	clock.Advance(time.Second)
+	d.Flush()
+	c.expect(t, "b")
+}
+
+func TestConcurrentTrigger(t *testing.T) {
+	c := &counter{}
+	d := New(time.Millisecond, Options{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				d.Trigger(c.fn("a"))
+			}
+		}()
+	}
+	wg.Wait()
+	d.Flush()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.calls) == 0 {
+		t.Error("no call")
 	}
 }
@@ -6,6 +6,33 @@ type Display interface {
 	Clear()
 	ClearLine(ofs int)
 	Close()
+	GetCapabilities() Capabilities
 	GetCharsPerLine() int
 	PrintLine(line int, text string, scroll bool)
 }
+
+// Capabilities describes what a display is able to show. It is used to adapt the text processing and the
+// layout to the connected display.
+type Capabilities struct {
+	Lines       int               // number of text lines
+	Columns     int               // number of characters per line
+	Width       int               // width in pixels (0 for character displays)
+	Height      int               // height in pixels (0 for character displays)
+	Backlight   bool              // backlight can be switched on and off
+	Contrast    bool              // contrast can be set by software
+	CustomChars bool              // user defined characters/glyphs can be shown
+	HasGlyph    func(r rune) bool // returns true if the rune can be displayed; nil means printable ASCII
+}
+
+// CanShow returns true if the given rune can be displayed without any translation
+func (c Capabilities) CanShow(r rune) bool {
+	if c.HasGlyph == nil {
+		return IsPrintableASCII(r)
+	}
+	return c.HasGlyph(r)
+}
+
+// IsPrintableASCII returns true for all printable ascii characters
+func IsPrintableASCII(r rune) bool {
+	return r >= 32 && r <= 126
+}
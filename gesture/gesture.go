@@ -0,0 +1,184 @@
+package gesture
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aluedtke7/piradio/input"
+)
+
+// Kind is the kind of gesture
+type Kind string
+
+const (
+	Short  Kind = "short"  // button was pressed and released
+	Long   Kind = "long"   // button was released after LongTime
+	Double Kind = "double" // button was pressed twice within DoubleTime
+	Hold   Kind = "hold"   // button is still pressed after HoldTime
+	Combo  Kind = "combo"  // two buttons are pressed together
+)
+
+// Gesture is the recognized gesture. Buttons contains the name of the button or for combos the names of both
+// buttons in alphabetical order joined by '+'.
+type Gesture struct {
+	Buttons string
+	Kind    Kind
+}
+
+// String returns the gesture in the form 'button.kind', e.g. "next.long" or "down+up.combo"
+func (g Gesture) String() string {
+	return g.Buttons + "." + string(g.Kind)
+}
+
+// Options configure the recognizer
+type Options struct {
+	LongTime   time.Duration
+	HoldTime   time.Duration
+	DoubleTime time.Duration
+	// buttons that can be double pressed. The short press of these buttons is delayed by DoubleTime.
+	DoubleButtons map[string]bool
+	// buttons that can be held. Only these report Hold, the others report Long when they are released.
+	HoldButtons map[string]bool
+}
+
+type button struct {
+	pressed      bool
+	pressedAt    time.Time
+	suppressed   bool // part of a combo or already reported as hold
+	second       bool // second press of a double press
+	pendingShort bool // short press that might become a double press
+	pendingSince time.Time
+}
+
+// Recognizer converts button events into gestures. It doesn't use timers itself: Tick must be called when the
+// deadline is reached. Run does this automatically.
+type Recognizer struct {
+	opts    Options
+	buttons map[string]*button
+}
+
+/**
+  Returns a new gesture recognizer
+*/
+func New(opts Options) *Recognizer {
+	return &Recognizer{opts: opts, buttons: map[string]*button{}}
+}
+
+func (r *Recognizer) button(name string) *button {
+	b := r.buttons[name]
+	if b == nil {
+		b = &button{}
+		r.buttons[name] = b
+	}
+	return b
+}
+
+// Handle processes a button event and returns the recognized gestures
+func (r *Recognizer) Handle(e input.Event) []Gesture {
+	b := r.button(e.Button)
+	if e.Type == input.Press {
+		if b.pressed {
+			return nil
+		}
+		for name, other := range r.buttons {
+			if name != e.Button && other.pressed && !other.suppressed {
+				other.suppressed = true
+				other.pendingShort = false
+				b.pressed, b.pressedAt, b.suppressed = true, e.Time, true
+				names := []string{name, e.Button}
+				sort.Strings(names)
+				return []Gesture{{Buttons: strings.Join(names, "+"), Kind: Combo}}
+			}
+		}
+		b.second = b.pendingShort && e.Time.Sub(b.pendingSince) < r.opts.DoubleTime
+		b.pendingShort = false
+		b.pressed, b.pressedAt, b.suppressed = true, e.Time, false
+		return nil
+	}
+
+	if !b.pressed {
+		return nil
+	}
+	b.pressed = false
+	if b.suppressed {
+		b.suppressed = false
+		return nil
+	}
+	switch {
+	case e.Time.Sub(b.pressedAt) >= r.opts.LongTime:
+		return []Gesture{{Buttons: e.Button, Kind: Long}}
+	case b.second:
+		b.second = false
+		return []Gesture{{Buttons: e.Button, Kind: Double}}
+	case r.opts.DoubleButtons[e.Button]:
+		b.pendingShort = true
+		b.pendingSince = e.Time
+		return nil
+	}
+	return []Gesture{{Buttons: e.Button, Kind: Short}}
+}
+
+// Tick returns the gestures that depend on the time: hold and the delayed short press
+func (r *Recognizer) Tick(now time.Time) []Gesture {
+	var gestures []Gesture
+	for name, b := range r.buttons {
+		if b.pressed && !b.suppressed && r.opts.HoldButtons[name] && now.Sub(b.pressedAt) >= r.opts.HoldTime {
+			b.suppressed = true
+			gestures = append(gestures, Gesture{Buttons: name, Kind: Hold})
+		}
+		if b.pendingShort && now.Sub(b.pendingSince) >= r.opts.DoubleTime {
+			b.pendingShort = false
+			gestures = append(gestures, Gesture{Buttons: name, Kind: Short})
+		}
+	}
+	return gestures
+}
+
+// Deadline returns the next time Tick has to be called
+func (r *Recognizer) Deadline() (deadline time.Time, ok bool) {
+	for name, b := range r.buttons {
+		var d time.Time
+		switch {
+		case b.pressed && !b.suppressed && r.opts.HoldButtons[name]:
+			d = b.pressedAt.Add(r.opts.HoldTime)
+		case b.pendingShort:
+			d = b.pendingSince.Add(r.opts.DoubleTime)
+		default:
+			continue
+		}
+		if !ok || d.Before(deadline) {
+			deadline, ok = d, true
+		}
+	}
+	return deadline, ok
+}
+
+// Run reads the button events and sends the recognized gestures to the returned channel. The channel is
+// closed, when the event channel is closed.
+func (r *Recognizer) Run(events <-chan input.Event) <-chan Gesture {
+	out := make(chan Gesture, 8)
+	go func() {
+		defer close(out)
+		for {
+			var timeout <-chan time.Time
+			if d, ok := r.Deadline(); ok {
+				timeout = time.After(time.Until(d))
+			}
+			var gestures []Gesture
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				gestures = r.Handle(e)
+			case now := <-timeout:
+				gestures = r.Tick(now)
+			}
+			for _, g := range gestures {
+				out <- g
+			}
+		}
+	}()
+	return out
+}
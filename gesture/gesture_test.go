@@ -0,0 +1,131 @@
+package gesture
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aluedtke7/piradio/input"
+)
+
+var t0 = time.Unix(0, 0)
+
+func ms(n int) time.Time {
+	return t0.Add(time.Duration(n) * time.Millisecond)
+}
+
+func press(button string, at int) input.Event {
+	return input.Event{Button: button, Type: input.Press, Time: ms(at)}
+}
+
+func release(button string, at int) input.Event {
+	return input.Event{Button: button, Type: input.Release, Time: ms(at)}
+}
+
+func newRecognizer() *Recognizer {
+	return New(Options{
+		LongTime:      800 * time.Millisecond,
+		HoldTime:      5 * time.Second,
+		DoubleTime:    300 * time.Millisecond,
+		DoubleButtons: map[string]bool{"mute": true},
+		HoldButtons:   map[string]bool{"mute": true},
+	})
+}
+
+// step is either an event or a tick at the given time (when event is nil)
+type step struct {
+	event *input.Event
+	tick  int
+}
+
+func ev(e input.Event) step { return step{event: &e} }
+func tick(at int) step      { return step{tick: at} }
+
+func TestRecognizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+		want  []string
+	}{
+		{"Short", []step{ev(press("next", 0)), ev(release("next", 100))}, []string{"next.short"}},
+		{"Long", []step{ev(press("next", 0)), ev(release("next", 1000))}, []string{"next.long"}},
+		{"Hold", []step{ev(press("mute", 0)), tick(4999), tick(5000), ev(release("mute", 6000))},
+			[]string{"mute.hold"}},
+		{"Combo", []step{ev(press("up", 0)), ev(press("down", 50)), ev(release("up", 200)),
+			ev(release("down", 250))}, []string{"down+up.combo"}},
+		{"ComboNoHold", []step{ev(press("up", 0)), ev(press("down", 50)), tick(6000)},
+			[]string{"down+up.combo"}},
+		{"Double", []step{ev(press("mute", 0)), ev(release("mute", 100)), ev(press("mute", 200)),
+			ev(release("mute", 300)), tick(700)}, []string{"mute.double"}},
+		{"DelayedShort", []step{ev(press("mute", 0)), ev(release("mute", 100)), tick(300), tick(400)},
+			[]string{"mute.short"}},
+		{"TwoShorts", []step{ev(press("mute", 0)), ev(release("mute", 100)), tick(400),
+			ev(press("mute", 500)), ev(release("mute", 600)), tick(900)}, []string{"mute.short", "mute.short"}},
+		{"NoDoubleForNext", []step{ev(press("next", 0)), ev(release("next", 100)), ev(press("next", 200)),
+			ev(release("next", 300))}, []string{"next.short", "next.short"}},
+		{"LongWithoutHold", []step{ev(press("next", 0)), tick(5000), tick(6000), ev(release("next", 6000))},
+			[]string{"next.long"}},
+		{"ReleaseWithoutPress", []step{ev(release("next", 100))}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecognizer()
+			var got []string
+			for _, s := range tt.steps {
+				var gestures []Gesture
+				if s.event != nil {
+					gestures = r.Handle(*s.event)
+				} else {
+					gestures = r.Tick(ms(s.tick))
+				}
+				for _, g := range gestures {
+					got = append(got, g.String())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	r := newRecognizer()
+	if _, ok := r.Deadline(); ok {
+		t.Error("no deadline expected")
+	}
+	r.Handle(press("next", 0))
+	if _, ok := r.Deadline(); ok {
+		t.Error("no hold deadline expected for next")
+	}
+	r.Handle(release("next", 100))
+	r.Handle(press("mute", 200))
+	if d, ok := r.Deadline(); !ok || !d.Equal(ms(5200)) {
+		t.Error("hold deadline", d, ok)
+	}
+	r.Handle(release("mute", 300))
+	if d, ok := r.Deadline(); !ok || !d.Equal(ms(600)) {
+		t.Error("double deadline", d, ok)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := New(Options{LongTime: time.Second, HoldTime: 20 * time.Millisecond, DoubleTime: 10 * time.Millisecond,
+		HoldButtons: map[string]bool{"mute": true}})
+	events := make(chan input.Event)
+	gestures := r.Run(events)
+
+	events <- input.Event{Button: "mute", Type: input.Press, Time: time.Now()}
+	select {
+	case g := <-gestures:
+		if g.String() != "mute.hold" {
+			t.Error("mute.hold", g)
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout")
+	}
+	close(events)
+	if _, ok := <-gestures; ok {
+		t.Error("channel must be closed")
+	}
+}
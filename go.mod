@@ -7,7 +7,9 @@ require (
 	github.com/d2r2/go-hd44780 v0.0.0-20181002113701-74cc28c83a3e
 	github.com/d2r2/go-i2c v0.0.0-20191123181816-73a8a799d6bc
 	github.com/d2r2/go-logger v0.0.0-20210606094344-60e9d1233e22
+	github.com/godbus/dbus/v5 v5.1.0
 	golang.org/x/image v0.0.0-20220321031419-a8550c1d254a
+	golang.org/x/text v0.3.8
 	periph.io/x/periph v3.6.8+incompatible
 )
 
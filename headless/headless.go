@@ -0,0 +1,71 @@
+package headless
+
+import (
+	"sync"
+	"unicode"
+
+	"github.com/aluedtke7/piradio/display"
+	"github.com/antigloss/go/logger"
+)
+
+const (
+	numChars = 20
+	numLines = 4
+)
+
+// headless is a display without hardware. Changed lines are written to the log file.
+type headless struct {
+	mutex sync.Mutex
+	lines [numLines]string
+}
+
+func (h *headless) Backlight(on bool) {
+	// nothing to do here: there's no hardware
+}
+
+func (h *headless) Clear() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := range h.lines {
+		h.lines[i] = ""
+	}
+}
+
+func (h *headless) ClearLine(ofs int) {
+	h.PrintLine(ofs, "", false)
+}
+
+func (h *headless) Close() {
+}
+
+func (h *headless) GetCapabilities() display.Capabilities {
+	return display.Capabilities{
+		Lines:    numLines,
+		Columns:  numChars,
+		HasGlyph: unicode.IsPrint, // the text isn't shown on a hardware display
+	}
+}
+
+func (h *headless) GetCharsPerLine() int {
+	return numChars
+}
+
+func (h *headless) PrintLine(line int, text string, scroll bool) {
+	if line < 0 || line >= numLines {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.lines[line] != text {
+		h.lines[line] = text
+		logger.Tracef("Display line %d: %s", line, text)
+	}
+}
+
+/**
+Returns a display without hardware, that is used when no display is connected
+*/
+func New() display.Display {
+	logger.Trace("Headless display initializing...")
+	return &headless{}
+}
@@ -0,0 +1,227 @@
+package input
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// BusBluetooth is the bus type of input devices connected via bluetooth (e.g. the AVRCP keys of a speaker)
+const BusBluetooth = 0x05
+
+// event types and values of the Linux input subsystem
+const (
+	evKey        = 0x01
+	keyPressed   = 1
+	keyRepeated  = 2
+	defaultScan  = 2 * time.Second
+	sysInputRoot = "/sys/class/input"
+	devInputRoot = "/dev/input"
+)
+
+// names of the keys that are useful for a radio, other keys are named by their code (e.g. "KEY_240")
+var keyNames = map[uint16]string{
+	1: "KEY_ESC", 2: "KEY_1", 3: "KEY_2", 4: "KEY_3", 5: "KEY_4", 6: "KEY_5", 7: "KEY_6", 8: "KEY_7", 9: "KEY_8",
+	10: "KEY_9", 11: "KEY_0", 12: "KEY_MINUS", 13: "KEY_EQUAL", 14: "KEY_BACKSPACE", 15: "KEY_TAB", 28: "KEY_ENTER",
+	55: "KEY_KPASTERISK", 57: "KEY_SPACE", 71: "KEY_KP7", 72: "KEY_KP8", 73: "KEY_KP9", 74: "KEY_KPMINUS",
+	75: "KEY_KP4", 76: "KEY_KP5", 77: "KEY_KP6", 78: "KEY_KPPLUS", 79: "KEY_KP1", 80: "KEY_KP2", 81: "KEY_KP3",
+	82: "KEY_KP0", 83: "KEY_KPDOT", 96: "KEY_KPENTER", 98: "KEY_KPSLASH", 102: "KEY_HOME", 103: "KEY_UP",
+	104: "KEY_PAGEUP", 105: "KEY_LEFT", 106: "KEY_RIGHT", 107: "KEY_END", 108: "KEY_DOWN", 109: "KEY_PAGEDOWN",
+	113: "KEY_MUTE", 114: "KEY_VOLUMEDOWN", 115: "KEY_VOLUMEUP", 116: "KEY_POWER", 119: "KEY_PAUSE",
+	163: "KEY_NEXTSONG", 164: "KEY_PLAYPAUSE", 165: "KEY_PREVIOUSSONG", 166: "KEY_STOPCD", 168: "KEY_REWIND",
+	200: "KEY_PLAYCD", 201: "KEY_PAUSECD", 207: "KEY_PLAY", 208: "KEY_FASTFORWARD", 352: "KEY_OK",
+	402: "KEY_CHANNELUP", 403: "KEY_CHANNELDOWN", 407: "KEY_NEXT", 412: "KEY_PREVIOUS",
+	512: "KEY_NUMERIC_0", 513: "KEY_NUMERIC_1", 514: "KEY_NUMERIC_2", 515: "KEY_NUMERIC_3", 516: "KEY_NUMERIC_4",
+	517: "KEY_NUMERIC_5", 518: "KEY_NUMERIC_6", 519: "KEY_NUMERIC_7", 520: "KEY_NUMERIC_8", 521: "KEY_NUMERIC_9",
+}
+
+// KeyName returns the name of a key code as used by the kernel and lircd
+func KeyName(code uint16) string {
+	if name, ok := keyNames[code]; ok {
+		return name
+	}
+	return "KEY_" + strconv.Itoa(int(code))
+}
+
+// struct input_event of the kernel, the size of the time stamp depends on the architecture
+type rawEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// Device is an event device of the Linux input subsystem
+type Device struct {
+	Path string // e.g. /dev/input/event3
+	Name string // e.g. "Logitech USB Keyboard"
+	Bus  uint16 // bus type, e.g. 0x03 for USB
+}
+
+// Devices returns the event devices listed in 'sysRoot' (usually /sys/class/input). The device files are expected
+// in 'devRoot' (usually /dev/input).
+func Devices(sysRoot, devRoot string) ([]Device, error) {
+	paths, err := filepath.Glob(filepath.Join(sysRoot, "event*"))
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, p := range paths {
+		name, err := os.ReadFile(filepath.Join(p, "device", "name"))
+		if err != nil {
+			continue // the device was removed in the meantime
+		}
+		d := Device{Path: filepath.Join(devRoot, filepath.Base(p)), Name: strings.TrimSpace(string(name))}
+		if bus, err := os.ReadFile(filepath.Join(p, "device", "id", "bustype")); err == nil {
+			if b, err := strconv.ParseUint(strings.TrimSpace(string(bus)), 16, 16); err == nil {
+				d.Bus = uint16(b)
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// EvdevOptions configure the keyboard input
+type EvdevOptions struct {
+	SysRoot      string        // defaults to /sys/class/input
+	DevRoot      string        // defaults to /dev/input
+	Names        []string      // only devices whose name contains one of these texts are used; empty for all
+	ScanInterval time.Duration // interval to look for new devices
+}
+
+// Evdev reads the keys of keyboards and other input devices. Devices that are plugged in later are detected.
+type Evdev struct {
+	opts  EvdevOptions
+	keys  chan Key
+	done  chan struct{}
+	mutex sync.Mutex
+	open  map[string]*os.File
+	wg    sync.WaitGroup
+}
+
+/**
+  Starts reading the keys of all input devices that match the options
+*/
+func NewEvdev(opts EvdevOptions) *Evdev {
+	if opts.SysRoot == "" {
+		opts.SysRoot = sysInputRoot
+	}
+	if opts.DevRoot == "" {
+		opts.DevRoot = devInputRoot
+	}
+	if opts.ScanInterval <= 0 {
+		opts.ScanInterval = defaultScan
+	}
+	e := &Evdev{opts: opts, keys: make(chan Key, 16), done: make(chan struct{}), open: map[string]*os.File{}}
+	e.wg.Add(1)
+	go e.scan()
+	return e
+}
+
+// Keys returns the channel with the pressed keys
+func (e *Evdev) Keys() <-chan Key {
+	return e.keys
+}
+
+// Close closes all devices and the channel with the keys
+func (e *Evdev) Close() {
+	close(e.done)
+	e.mutex.Lock()
+	for _, f := range e.open {
+		_ = f.Close()
+	}
+	e.mutex.Unlock()
+	e.wg.Wait()
+	close(e.keys)
+}
+
+func (e *Evdev) matches(d Device) bool {
+	if len(e.opts.Names) == 0 {
+		return true
+	}
+	for _, n := range e.opts.Names {
+		if strings.Contains(strings.ToLower(d.Name), strings.ToLower(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// looks for new devices until the reader is closed
+func (e *Evdev) scan() {
+	defer e.wg.Done()
+	for {
+		devices, _ := Devices(e.opts.SysRoot, e.opts.DevRoot)
+		for _, d := range devices {
+			if e.matches(d) {
+				e.openDevice(d)
+			}
+		}
+		select {
+		case <-e.done:
+			return
+		case <-time.After(e.opts.ScanInterval):
+		}
+	}
+}
+
+func (e *Evdev) openDevice(d Device) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if _, ok := e.open[d.Path]; ok {
+		return
+	}
+	select {
+	case <-e.done:
+		return
+	default:
+	}
+	f, err := os.Open(d.Path)
+	if err != nil {
+		return // e.g. missing permission, tried again with the next scan
+	}
+	e.open[d.Path] = f
+	e.wg.Add(1)
+	go e.read(d, f)
+}
+
+// reads the events of a device until it's removed or the reader is closed
+func (e *Evdev) read(d Device, f *os.File) {
+	defer e.wg.Done()
+	defer func() {
+		e.mutex.Lock()
+		delete(e.open, d.Path)
+		e.mutex.Unlock()
+		_ = f.Close()
+	}()
+	r := bufio.NewReader(f)
+	repeat := map[uint16]int{}
+	var ev rawEvent
+	// the raspberry pi is little endian
+	for binary.Read(r, binary.LittleEndian, &ev) == nil {
+		if ev.Type != evKey {
+			continue
+		}
+		switch ev.Value {
+		case keyPressed:
+			repeat[ev.Code] = 0
+		case keyRepeated:
+			repeat[ev.Code]++
+		default:
+			continue
+		}
+		key := Key{Code: strconv.Itoa(int(ev.Code)), Repeat: repeat[ev.Code], Button: KeyName(ev.Code), Remote: d.Name}
+		select {
+		case e.keys <- key:
+		case <-e.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// creates a fake input device in the fake sysfs and returns the writing end of the device
+func newFakeDevice(t *testing.T, sysRoot, devRoot, event, name, bus string) *os.File {
+	t.Helper()
+	devPath := filepath.Join(devRoot, event)
+	if err := syscall.Mkfifo(devPath, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// opened for reading and writing, so that opening the device doesn't block
+	w, err := os.OpenFile(devPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(sysRoot, event, "device")
+	if err := os.MkdirAll(filepath.Join(dir, "id"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "id", "bustype"), []byte(bus+"\n"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "name"), []byte(name+"\n"), 0600)
+	return w
+}
+
+func writeEvents(t *testing.T, w *os.File, events ...rawEvent) {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, ev := range events {
+		_ = binary.Write(&buf, binary.LittleEndian, ev)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keyEvent(code uint16, value int32) rawEvent {
+	return rawEvent{Type: evKey, Code: code, Value: value}
+}
+
+func TestDevices(t *testing.T) {
+	sysRoot, devRoot := t.TempDir(), t.TempDir()
+	newFakeDevice(t, sysRoot, devRoot, "event0", "JBL Flip 4 (AVRCP)", "0005").Close()
+	newFakeDevice(t, sysRoot, devRoot, "event1", "USB Keyboard", "0003").Close()
+	devices, err := Devices(sysRoot, devRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Device{
+		{Path: filepath.Join(devRoot, "event0"), Name: "JBL Flip 4 (AVRCP)", Bus: BusBluetooth},
+		{Path: filepath.Join(devRoot, "event1"), Name: "USB Keyboard", Bus: 0x03},
+	}
+	if len(devices) != len(want) || devices[0] != want[0] || devices[1] != want[1] {
+		t.Errorf("got %+v, want %+v", devices, want)
+	}
+}
+
+func TestEvdev(t *testing.T) {
+	sysRoot, devRoot := t.TempDir(), t.TempDir()
+	mouse := newFakeDevice(t, sysRoot, devRoot, "event0", "USB Mouse", "0003")
+	defer mouse.Close()
+	e := NewEvdev(EvdevOptions{SysRoot: sysRoot, DevRoot: devRoot, Names: []string{"keyboard", "numpad"},
+		ScanInterval: 10 * time.Millisecond})
+
+	// plugged in after the start
+	kbd := newFakeDevice(t, sysRoot, devRoot, "event1", "Media Keyboard", "0003")
+	defer kbd.Close()
+	writeEvents(t, mouse, keyEvent(115, keyPressed))
+	writeEvents(t, kbd, rawEvent{Type: 0x04, Code: 4, Value: 458792}, keyEvent(115, keyPressed),
+		rawEvent{}, keyEvent(115, keyRepeated), keyEvent(115, keyRepeated), keyEvent(115, 0),
+		keyEvent(163, keyPressed), keyEvent(163, 0), keyEvent(240, keyPressed))
+	expectKey(t, e.Keys(), "KEY_VOLUMEUP", 0)
+	expectKey(t, e.Keys(), "KEY_VOLUMEUP", 1)
+	expectKey(t, e.Keys(), "KEY_VOLUMEUP", 2)
+	expectKey(t, e.Keys(), "KEY_NEXTSONG", 0)
+	expectKey(t, e.Keys(), "KEY_240", 0)
+
+	e.Close()
+	if _, ok := <-e.Keys(); ok {
+		t.Error("keys channel not closed")
+	}
+}
@@ -0,0 +1,48 @@
+package input
+
+import (
+	"time"
+)
+
+// filter is the debounce state machine of a single pin. A new level is only accepted, when it was stable for
+// the given time. Every bounce restarts the time measurement.
+type filter struct {
+	stableTime time.Duration
+	stable     bool // the accepted state (true = pressed)
+	pending    bool // a state change is pending
+	since      time.Time
+}
+
+func newFilter(stableTime time.Duration, pressed bool) *filter {
+	return &filter{stableTime: stableTime, stable: pressed}
+}
+
+// update feeds the actual state of the pin into the filter. The result is true, when the accepted state changed.
+func (f *filter) update(pressed bool, now time.Time) bool {
+	if pressed == f.stable {
+		f.pending = false
+		return false
+	}
+	if !f.pending {
+		f.pending = true
+		f.since = now
+	}
+	if now.Sub(f.since) >= f.stableTime {
+		f.stable = pressed
+		f.pending = false
+		return true
+	}
+	return false
+}
+
+// returns the time until a pending change would be accepted, or -1 if no change is pending
+func (f *filter) remaining(now time.Time) time.Duration {
+	if !f.pending {
+		return -1
+	}
+	r := f.stableTime - now.Sub(f.since)
+	if r < 0 {
+		return 0
+	}
+	return r
+}
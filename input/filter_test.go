@@ -0,0 +1,53 @@
+package input
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	ms := func(n int) time.Time { return t0.Add(time.Duration(n) * time.Millisecond) }
+	f := newFilter(20*time.Millisecond, false)
+
+	steps := []struct {
+		at      int
+		pressed bool
+		changed bool
+	}{
+		{0, true, false},   // press starts
+		{3, false, false},  // bounce
+		{5, true, false},   // bounce, time restarts
+		{20, true, false},  // only 15ms stable
+		{25, true, true},   // 20ms stable -> pressed
+		{100, true, false}, // holding doesn't retrigger
+		{200, false, false},
+		{205, true, false}, // bounce on release
+		{210, false, false},
+		{229, false, false},
+		{230, false, true}, // released
+	}
+	for _, s := range steps {
+		if changed := f.update(s.pressed, ms(s.at)); changed != s.changed {
+			t.Errorf("%dms: changed = %v, want %v", s.at, changed, s.changed)
+		}
+	}
+	if f.stable {
+		t.Error("must be released")
+	}
+}
+
+func TestFilterRemaining(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	f := newFilter(20*time.Millisecond, false)
+	if f.remaining(t0) != -1 {
+		t.Error("nothing pending")
+	}
+	f.update(true, t0)
+	if r := f.remaining(t0.Add(5 * time.Millisecond)); r != 15*time.Millisecond {
+		t.Error("remaining", r)
+	}
+	if r := f.remaining(t0.Add(50 * time.Millisecond)); r != 0 {
+		t.Error("remaining", r)
+	}
+}
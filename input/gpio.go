@@ -0,0 +1,97 @@
+package input
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// idle time after which a waiting pin checks if the input was closed
+const idleTimeout = 500 * time.Millisecond
+
+// Button is a push button connected to a GPIO pin
+type Button struct {
+	Name      string
+	Pin       gpio.PinIn
+	Pull      gpio.Pull
+	ActiveLow bool // the button pulls the pin to ground when pressed
+}
+
+// GPIO reads buttons via the edge detection of the GPIO pins and sends debounced press and release events
+type GPIO struct {
+	buttons    []Button
+	stableTime time.Duration
+	events     chan Event
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+/**
+  Configures the pins of the buttons as inputs with edge detection and starts listening. A level change is only
+  accepted, when it's stable for 'stableTime'.
+*/
+func NewGPIO(buttons []Button, stableTime time.Duration) (*GPIO, error) {
+	g := &GPIO{buttons: buttons, stableTime: stableTime, events: make(chan Event, 16), done: make(chan struct{})}
+	for _, b := range buttons {
+		if b.Pin == nil {
+			return nil, fmt.Errorf("button %s has no pin", b.Name)
+		}
+		if err := b.Pin.In(b.Pull, gpio.BothEdges); err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range buttons {
+		g.wg.Add(1)
+		go g.listen(b)
+	}
+	return g, nil
+}
+
+// Events returns the channel with the button events
+func (g *GPIO) Events() <-chan Event {
+	return g.events
+}
+
+// Close stops listening and closes the event channel
+func (g *GPIO) Close() {
+	close(g.done)
+	g.wg.Wait()
+	close(g.events)
+}
+
+func (g *GPIO) pressed(b Button) bool {
+	return (b.Pin.Read() == gpio.Low) == b.ActiveLow
+}
+
+// waits for edges of the pin and feeds the level into the filter. While a change is pending, the wait is limited
+// to the remaining stable time, so that the change is accepted without another edge.
+func (g *GPIO) listen(b Button) {
+	defer g.wg.Done()
+	f := newFilter(g.stableTime, g.pressed(b))
+	for {
+		select {
+		case <-g.done:
+			return
+		default:
+		}
+		timeout := f.remaining(time.Now())
+		if timeout < 0 {
+			timeout = idleTimeout
+		}
+		b.Pin.WaitForEdge(timeout)
+		now := time.Now()
+		if f.update(g.pressed(b), now) {
+			e := Event{Button: b.Name, Type: Release, Time: now}
+			if f.stable {
+				e.Type = Press
+			}
+			select {
+			case g.events <- e:
+			case <-g.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func newPin(name string) *gpiotest.Pin {
+	return &gpiotest.Pin{N: name, EdgesChan: make(chan gpio.Level, 16)}
+}
+
+func expectEvent(t *testing.T, events <-chan Event, button string, typ EventType) {
+	t.Helper()
+	select {
+	case e := <-events:
+		if e.Button != button || e.Type != typ {
+			t.Errorf("got %s %s, want %s %s", e.Button, e.Type, button, typ)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timeout waiting for %s %s", button, typ)
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan Event) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %s %s", e.Button, e.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGPIO(t *testing.T) {
+	next := newPin("GPIO5")
+	mute := newPin("GPIO16")
+	g, err := NewGPIO([]Button{
+		{Name: "next", Pin: next, Pull: gpio.PullUp, ActiveLow: true},
+		{Name: "mute", Pin: mute, Pull: gpio.PullUp, ActiveLow: true},
+	}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// bouncing press
+	next.EdgesChan <- gpio.Low
+	next.EdgesChan <- gpio.High
+	next.EdgesChan <- gpio.Low
+	expectEvent(t, g.Events(), "next", Press)
+	expectNoEvent(t, g.Events())
+
+	next.EdgesChan <- gpio.High
+	expectEvent(t, g.Events(), "next", Release)
+
+	// a glitch shorter than the stable time is ignored
+	mute.EdgesChan <- gpio.Low
+	mute.EdgesChan <- gpio.High
+	expectNoEvent(t, g.Events())
+
+	mute.EdgesChan <- gpio.Low
+	expectEvent(t, g.Events(), "mute", Press)
+}
+
+func TestGPIOActiveHigh(t *testing.T) {
+	p := newPin("GPIO20")
+	g, err := NewGPIO([]Button{{Name: "up", Pin: p, Pull: gpio.PullDown}}, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	p.EdgesChan <- gpio.High
+	expectEvent(t, g.Events(), "up", Press)
+	p.EdgesChan <- gpio.Low
+	expectEvent(t, g.Events(), "up", Release)
+}
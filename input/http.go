@@ -0,0 +1,64 @@
+package input
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HTTP receives actions via HTTP: 'POST /action/<name>' (e.g. '/action/digit2') sends the action name. There's no
+// authentication, the address should only be reachable from a trusted network.
+type HTTP struct {
+	valid   func(name string) bool
+	actions chan string
+	server  *http.Server
+}
+
+/**
+  Starts listening on the address (e.g. "127.0.0.1:8080"). Only the actions accepted by 'valid' are sent, the
+  others are answered with 404.
+*/
+func NewHTTP(listen string, valid func(name string) bool) (*HTTP, error) {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+	h := &HTTP{valid: valid, actions: make(chan string, 16)}
+	h.server = &http.Server{Handler: h.Handler()}
+	go func() {
+		_ = h.server.Serve(listener)
+	}()
+	return h, nil
+}
+
+// Actions returns the channel with the names of the received actions
+func (h *HTTP) Actions() <-chan string {
+	return h.actions
+}
+
+// Handler returns the handler of the requests
+func (h *HTTP) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/action/")
+		if name == r.URL.Path || name == "" || !h.valid(name) {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		select {
+		case h.actions <- name:
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Close stops listening
+func (h *HTTP) Close() {
+	_ = h.server.Close()
+}
@@ -0,0 +1,35 @@
+package input
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP(t *testing.T) {
+	h := &HTTP{
+		valid:   func(name string) bool { return name == "digit2" || name == "confirm" },
+		actions: make(chan string, 1),
+	}
+	for _, tt := range []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/action/digit2", http.StatusAccepted},
+		{http.MethodPost, "/action/confirm", http.StatusServiceUnavailable}, // the channel is full
+		{http.MethodGet, "/action/digit2", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/action/shutdown", http.StatusNotFound},
+		{http.MethodPost, "/action/", http.StatusNotFound},
+		{http.MethodPost, "/digit2", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		h.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+	if name := <-h.Actions(); name != "digit2" {
+		t.Errorf("got %s, want digit2", name)
+	}
+}
@@ -0,0 +1,27 @@
+package input
+
+import (
+	"time"
+)
+
+// EventType is the type of button event
+type EventType int
+
+const (
+	Press   EventType = iota // the button was pressed
+	Release                  // the button was released
+)
+
+func (t EventType) String() string {
+	if t == Press {
+		return "press"
+	}
+	return "release"
+}
+
+// Event is sent by the input sources when a button is pressed or released
+type Event struct {
+	Button string
+	Type   EventType
+	Time   time.Time
+}
@@ -0,0 +1,138 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limits for the delay between two connection attempts to lircd
+const (
+	lircMinBackoff = time.Second
+	lircMaxBackoff = 30 * time.Second
+)
+
+// Key is a key of a remote control as reported by lircd
+type Key struct {
+	Code   string // scan code of the key, e.g. "0000000000000490"
+	Repeat int    // number of repeats of a held key, 0 for the first press
+	Button string // name of the key, e.g. "KEY_VOLUMEUP"
+	Remote string // name of the remote control
+}
+
+// ParseLIRC parses a line of the lircd socket in the format "code repeat button remote". The repeat count is
+// hexadecimal.
+func ParseLIRC(line string) (Key, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return Key{}, fmt.Errorf("invalid lirc line %q", line)
+	}
+	repeat, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid repeat count in lirc line %q", line)
+	}
+	return Key{Code: fields[0], Repeat: int(repeat), Button: fields[2], Remote: fields[3]}, nil
+}
+
+// LIRC reads the keys of remote controls from the Unix socket of lircd. A lost connection is reestablished.
+type LIRC struct {
+	socket string
+	keys   chan Key
+	done   chan struct{}
+	mutex  sync.Mutex
+	conn   net.Conn
+	wg     sync.WaitGroup
+}
+
+/**
+  Connects to the lircd socket (e.g. /var/run/lirc/lircd) and starts listening. When lircd isn't running yet,
+  the connection is retried in the background.
+*/
+func NewLIRC(socket string) *LIRC {
+	l := &LIRC{socket: socket, keys: make(chan Key, 16), done: make(chan struct{})}
+	l.wg.Add(1)
+	go l.run()
+	return l
+}
+
+// Keys returns the channel with the received keys
+func (l *LIRC) Keys() <-chan Key {
+	return l.keys
+}
+
+// Close closes the connection and the channel with the keys
+func (l *LIRC) Close() {
+	close(l.done)
+	l.mutex.Lock()
+	if l.conn != nil {
+		_ = l.conn.Close()
+	}
+	l.mutex.Unlock()
+	l.wg.Wait()
+	close(l.keys)
+}
+
+func (l *LIRC) run() {
+	defer l.wg.Done()
+	backoff := lircMinBackoff
+	for {
+		conn, err := net.Dial("unix", l.socket)
+		if err == nil {
+			backoff = lircMinBackoff
+			if !l.read(conn) {
+				return
+			}
+		}
+		select {
+		case <-l.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > lircMaxBackoff {
+			backoff = lircMaxBackoff
+		}
+	}
+}
+
+// reads the keys until the connection is lost. Returns false, when the reader was closed.
+func (l *LIRC) read(conn net.Conn) bool {
+	l.mutex.Lock()
+	select {
+	case <-l.done:
+		l.mutex.Unlock()
+		_ = conn.Close()
+		return false
+	default:
+	}
+	l.conn = conn
+	l.mutex.Unlock()
+	defer func() {
+		l.mutex.Lock()
+		l.conn = nil
+		l.mutex.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		key, err := ParseLIRC(scanner.Text())
+		if err != nil {
+			continue // e.g. replies to commands
+		}
+		select {
+		case l.keys <- key:
+		case <-l.done:
+			return false
+		}
+	}
+	select {
+	case <-l.done:
+		return false
+	default:
+		return true
+	}
+}
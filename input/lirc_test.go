@@ -0,0 +1,74 @@
+package input
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLIRC(t *testing.T) {
+	key, err := ParseLIRC("0000000000f40bf0 0a KEY_VOLUMEUP my_remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Key{Code: "0000000000f40bf0", Repeat: 10, Button: "KEY_VOLUMEUP", Remote: "my_remote"}
+	if key != want {
+		t.Errorf("got %+v, want %+v", key, want)
+	}
+	for _, line := range []string{"", "BEGIN", "0000000000f40bf0 zz KEY_MUTE my_remote", "a b c d e"} {
+		if _, err := ParseLIRC(line); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
+
+func expectKey(t *testing.T, keys <-chan Key, button string, repeat int) {
+	t.Helper()
+	select {
+	case k := <-keys:
+		if k.Button != button || k.Repeat != repeat {
+			t.Errorf("got %s/%d, want %s/%d", k.Button, k.Repeat, button, repeat)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout waiting for %s", button)
+	}
+}
+
+func accept(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestLIRC(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "lircd")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	l := NewLIRC(socket)
+	conn := accept(t, ln)
+	_, _ = conn.Write([]byte("000000000000000a 00 KEY_NEXT remote\nBEGIN\nSIGHUP\nEND\n"))
+	_, _ = conn.Write([]byte("0000000000000490 00 KEY_VOLUMEUP remote\n0000000000000490 01 KEY_VOLUMEUP remote\n"))
+	expectKey(t, l.Keys(), "KEY_NEXT", 0)
+	expectKey(t, l.Keys(), "KEY_VOLUMEUP", 0)
+	expectKey(t, l.Keys(), "KEY_VOLUMEUP", 1)
+
+	// lircd restarts: the connection is reestablished
+	_ = conn.Close()
+	conn = accept(t, ln)
+	_, _ = conn.Write([]byte("0000000000000290 00 KEY_MUTE remote\n"))
+	expectKey(t, l.Keys(), "KEY_MUTE", 0)
+
+	l.Close()
+	_ = conn.Close()
+	if _, ok := <-l.Keys(); ok {
+		t.Error("keys channel not closed")
+	}
+}
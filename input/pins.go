@@ -0,0 +1,94 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+)
+
+// PinConfig is the configured pin of a button
+type PinConfig struct {
+	Name      string // name of the button, e.g. "next"
+	Pin       string // name of the pin as known by gpioreg, e.g. "GPIO5"; empty if the button is disabled
+	Pull      gpio.Pull
+	ActiveLow bool
+}
+
+// ParsePinConfig parses a configuration like "GPIO5", "GPIO23, down, high" or "none". The pull mode ("up", "down",
+// "float") and the active level ("low", "high") are optional and taken from 'def' when missing.
+func ParsePinConfig(value string, def PinConfig) (PinConfig, error) {
+	c := def
+	fields := strings.Split(value, ",")
+	c.Pin = strings.TrimSpace(fields[0])
+	if strings.EqualFold(c.Pin, "none") {
+		c.Pin = ""
+	}
+	for _, f := range fields[1:] {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "up", "pullup":
+			c.Pull = gpio.PullUp
+		case "down", "pulldown":
+			c.Pull = gpio.PullDown
+		case "float", "nopull":
+			c.Pull = gpio.Float
+		case "low":
+			c.ActiveLow = true
+		case "high":
+			c.ActiveLow = false
+		default:
+			return def, fmt.Errorf("unknown pin option %q for %s", strings.TrimSpace(f), c.Name)
+		}
+	}
+	return c, nil
+}
+
+// Pins looks up pins in the gpio registry and makes sure that every pin is only used once
+type Pins struct {
+	used map[string]string // real pin name -> user
+}
+
+/**
+  Returns an empty set of used pins
+*/
+func NewPins() *Pins {
+	return &Pins{used: map[string]string{}}
+}
+
+// Lookup returns the pin with the given name (number or alias) and reserves it for 'user'. An error is returned,
+// when the pin doesn't exist or is already used.
+func (p *Pins) Lookup(user, name string) (gpio.PinIO, error) {
+	pin := gpioreg.ByName(name)
+	if pin == nil {
+		return nil, fmt.Errorf("%s: pin %s doesn't exist", user, name)
+	}
+	real := pin.Name()
+	if r, ok := pin.(gpio.RealPin); ok {
+		real = r.Real().Name()
+	}
+	if other, ok := p.used[real]; ok {
+		return nil, fmt.Errorf("%s: pin %s is already used by %s", user, name, other)
+	}
+	p.used[real] = user
+	return pin, nil
+}
+
+// Buttons looks up the pins of the enabled buttons. Buttons with a missing or conflicting pin are skipped and
+// reported in the returned errors.
+func (p *Pins) Buttons(configs []PinConfig) ([]Button, []error) {
+	var buttons []Button
+	var errs []error
+	for _, c := range configs {
+		if c.Pin == "" {
+			continue
+		}
+		pin, err := p.Lookup(c.Name, c.Pin)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		buttons = append(buttons, Button{Name: c.Name, Pin: pin, Pull: c.Pull, ActiveLow: c.ActiveLow})
+	}
+	return buttons, errs
+}
@@ -0,0 +1,85 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func init() {
+	for _, name := range []string{"TEST_PIN1", "TEST_PIN2", "TEST_PIN3"} {
+		if err := gpioreg.Register(&gpiotest.Pin{N: name}); err != nil {
+			panic(err)
+		}
+	}
+	if err := gpioreg.RegisterAlias("TEST_ALIAS1", "TEST_PIN1"); err != nil {
+		panic(err)
+	}
+}
+
+func TestParsePinConfig(t *testing.T) {
+	def := PinConfig{Name: "next", Pin: "GPIO5", Pull: gpio.PullUp, ActiveLow: true}
+	tests := []struct {
+		value string
+		want  PinConfig
+	}{
+		{"GPIO17", PinConfig{Name: "next", Pin: "GPIO17", Pull: gpio.PullUp, ActiveLow: true}},
+		{" GPIO23 , down, high", PinConfig{Name: "next", Pin: "GPIO23", Pull: gpio.PullDown, ActiveLow: false}},
+		{"P1_29, float", PinConfig{Name: "next", Pin: "P1_29", Pull: gpio.Float, ActiveLow: true}},
+		{"none", PinConfig{Name: "next", Pull: gpio.PullUp, ActiveLow: true}},
+		{"", PinConfig{Name: "next", Pull: gpio.PullUp, ActiveLow: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePinConfig(tt.value, def)
+		if err != nil {
+			t.Errorf("ParsePinConfig(%q) failed: %s", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParsePinConfig(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+	if _, err := ParsePinConfig("GPIO5, sideways", def); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+}
+
+func TestPinsButtons(t *testing.T) {
+	pins := NewPins()
+	buttons, errs := pins.Buttons([]PinConfig{
+		{Name: "next", Pin: "TEST_PIN1", Pull: gpio.PullUp, ActiveLow: true},
+		{Name: "prev", Pin: "TEST_ALIAS1"},
+		{Name: "up", Pin: "TEST_MISSING"},
+		{Name: "down"},
+		{Name: "mute", Pin: "TEST_PIN2", Pull: gpio.PullDown},
+	})
+	if len(buttons) != 2 || buttons[0].Name != "next" || buttons[1].Name != "mute" {
+		t.Fatalf("unexpected buttons %+v", buttons)
+	}
+	if buttons[1].Pull != gpio.PullDown || buttons[1].ActiveLow {
+		t.Errorf("unexpected configuration of mute: %+v", buttons[1])
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if want := "prev: pin TEST_ALIAS1 is already used by next"; errs[0].Error() != want {
+		t.Errorf("got error %q, want %q", errs[0], want)
+	}
+	if want := "up: pin TEST_MISSING doesn't exist"; errs[1].Error() != want {
+		t.Errorf("got error %q, want %q", errs[1], want)
+	}
+	if _, err := pins.Lookup("encoder.clk", "TEST_PIN2"); err == nil {
+		t.Error("expected a conflict for TEST_PIN2")
+	}
+	if _, err := pins.Lookup("encoder.clk", "TEST_PIN3"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGPIONilPin(t *testing.T) {
+	if _, err := NewGPIO([]Button{{Name: "next"}}, time.Millisecond); err == nil {
+		t.Error("expected an error for a button without pin")
+	}
+}
@@ -0,0 +1,152 @@
+package input
+
+import (
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// transitions of the quadrature signal: index is (previous state << 2) | state with state = (A << 1) | B.
+// Invalid transitions (e.g. caused by bouncing contacts or missed edges) count 0.
+var quadratureTable = [16]int{0, -1, 1, 0, 1, 0, 0, -1, -1, 0, 0, 1, 0, 1, -1, 0}
+
+// RotaryOptions configure the rotary encoder
+type RotaryOptions struct {
+	StepsPerDetent int           // quadrature steps per detent (4 for a KY-040)
+	AccelTime      time.Duration // detents that follow faster than this time accelerate the turn
+	MaxAccel       int           // the maximum acceleration factor
+	Pull           gpio.Pull     // pull mode of both pins
+}
+
+// quadrature decodes the two signals of the encoder into detents
+type quadrature struct {
+	state          int
+	count          int
+	stepsPerDetent int
+}
+
+// update feeds the levels of the pins into the decoder and returns +1 or -1, when a detent was completed
+func (q *quadrature) update(a bool, b bool) int {
+	state := 0
+	if a {
+		state |= 2
+	}
+	if b {
+		state |= 1
+	}
+	q.count += quadratureTable[q.state<<2|state]
+	q.state = state
+	switch {
+	case q.count >= q.stepsPerDetent:
+		q.count = 0
+		return 1
+	case q.count <= -q.stepsPerDetent:
+		q.count = 0
+		return -1
+	}
+	return 0
+}
+
+// accelerator increases the factor while the detents follow fast and in the same direction
+type accelerator struct {
+	accelTime time.Duration
+	maxAccel  int
+	factor    int
+	last      time.Time
+	direction int
+}
+
+func (a *accelerator) detent(direction int, now time.Time) int {
+	if a.direction == direction && now.Sub(a.last) < a.accelTime {
+		if a.factor < a.maxAccel {
+			a.factor++
+		}
+	} else {
+		a.factor = 1
+	}
+	a.direction = direction
+	a.last = now
+	return direction * a.factor
+}
+
+// Rotary reads a quadrature rotary encoder via the edge detection of the two pins
+type Rotary struct {
+	a, b  gpio.PinIn
+	mu    sync.Mutex
+	dec   quadrature
+	accel accelerator
+	turns chan int
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+/**
+  Configures the pins 'a' (CLK) and 'b' (DT) of the encoder as inputs with the pull mode of the options and edge
+  detection. The turns are sent as steps: positive values for clockwise and negative values for counterclockwise.
+*/
+func NewRotary(a gpio.PinIn, b gpio.PinIn, opts RotaryOptions) (*Rotary, error) {
+	if opts.StepsPerDetent < 1 {
+		opts.StepsPerDetent = 4
+	}
+	if opts.MaxAccel < 1 {
+		opts.MaxAccel = 1
+	}
+	r := &Rotary{
+		a:     a,
+		b:     b,
+		dec:   quadrature{stepsPerDetent: opts.StepsPerDetent},
+		accel: accelerator{accelTime: opts.AccelTime, maxAccel: opts.MaxAccel},
+		turns: make(chan int, 16),
+		done:  make(chan struct{}),
+	}
+	for _, p := range []gpio.PinIn{a, b} {
+		if err := p.In(opts.Pull, gpio.BothEdges); err != nil {
+			return nil, err
+		}
+	}
+	r.dec.update(a.Read() == gpio.High, b.Read() == gpio.High)
+	r.wg.Add(2)
+	go r.listen(a)
+	go r.listen(b)
+	return r, nil
+}
+
+// Turns returns the channel with the steps
+func (r *Rotary) Turns() <-chan int {
+	return r.turns
+}
+
+// Close stops listening and closes the channel
+func (r *Rotary) Close() {
+	close(r.done)
+	r.wg.Wait()
+	close(r.turns)
+}
+
+func (r *Rotary) listen(p gpio.PinIn) {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+		if !p.WaitForEdge(idleTimeout) {
+			continue
+		}
+		r.mu.Lock()
+		steps := 0
+		if d := r.dec.update(r.a.Read() == gpio.High, r.b.Read() == gpio.High); d != 0 {
+			steps = r.accel.detent(d, time.Now())
+		}
+		r.mu.Unlock()
+		if steps != 0 {
+			select {
+			case r.turns <- steps:
+			case <-r.done:
+				return
+			}
+		}
+	}
+}
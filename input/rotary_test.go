@@ -0,0 +1,108 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// levels of A and B for one detent clockwise, starting and ending at the idle state (both high)
+var clockwise = [][2]bool{{false, true}, {false, false}, {true, false}, {true, true}}
+
+func TestQuadrature(t *testing.T) {
+	q := quadrature{state: 3, stepsPerDetent: 4}
+	results := []int{}
+	for _, s := range clockwise {
+		results = append(results, q.update(s[0], s[1]))
+	}
+	if results[3] != 1 || results[0]+results[1]+results[2] != 0 {
+		t.Error("clockwise", results)
+	}
+	// counterclockwise: the same states in reverse order
+	sum := 0
+	for i := len(clockwise) - 2; i >= 0; i-- {
+		sum += q.update(clockwise[i][0], clockwise[i][1])
+	}
+	sum += q.update(true, true)
+	if sum != -1 {
+		t.Error("counterclockwise", sum)
+	}
+	// bouncing contact: A toggles several times, no detent
+	for i := 0; i < 5; i++ {
+		if q.update(false, true) != 0 || q.update(true, true) != 0 {
+			t.Error("bounce must not count")
+		}
+	}
+	// half steps per detent
+	q = quadrature{state: 3, stepsPerDetent: 2}
+	if q.update(false, true)+q.update(false, false) != 1 {
+		t.Error("2 steps per detent")
+	}
+}
+
+func TestAccelerator(t *testing.T) {
+	a := accelerator{accelTime: 50 * time.Millisecond, maxAccel: 3}
+	t0 := time.Unix(0, 0)
+	steps := []struct {
+		direction int
+		at        int
+		want      int
+	}{
+		{1, 0, 1},
+		{1, 200, 1},
+		{1, 220, 2},
+		{1, 240, 3},
+		{1, 260, 3},
+		{-1, 270, -1}, // direction change resets the acceleration
+		{-1, 500, -1},
+	}
+	for _, s := range steps {
+		if got := a.detent(s.direction, t0.Add(time.Duration(s.at)*time.Millisecond)); got != s.want {
+			t.Errorf("%dms: got %d, want %d", s.at, got, s.want)
+		}
+	}
+}
+
+func TestRotary(t *testing.T) {
+	a := newPin("GPIO17")
+	b := newPin("GPIO27")
+	r, err := NewRotary(a, b, RotaryOptions{StepsPerDetent: 4, Pull: gpio.PullUp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if a.P != gpio.PullUp || b.P != gpio.PullUp {
+		t.Errorf("pull: got %v/%v, want %v", a.P, b.P, gpio.PullUp)
+	}
+
+	turn := func(states [][2]bool) {
+		for _, s := range states {
+			if (a.Read() == gpio.High) != s[0] {
+				a.EdgesChan <- gpio.Level(s[0])
+			} else {
+				b.EdgesChan <- gpio.Level(s[1])
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	turn(clockwise)
+	select {
+	case steps := <-r.Turns():
+		if steps != 1 {
+			t.Error("clockwise", steps)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	turn([][2]bool{{true, false}, {false, false}, {false, true}, {true, true}})
+	select {
+	case steps := <-r.Turns():
+		if steps != -1 {
+			t.Error("counterclockwise", steps)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -156,6 +156,16 @@ func (l *lcd) GetCharsPerLine() int {
 	return l.charsPerLine
 }
 
+func (l *lcd) GetCapabilities() display.Capabilities {
+	return display.Capabilities{
+		Lines:       numLines,
+		Columns:     l.charsPerLine,
+		Backlight:   true,
+		CustomChars: true, // the HD44780 has 8 user definable characters in CGRAM
+		HasGlyph:    display.IsPrintableASCII,
+	}
+}
+
 func (l *lcd) retryDevice() {
 	log.Info("Start of retryDevice(): %d", l.retryCount)
 	var err error
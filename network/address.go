@@ -0,0 +1,182 @@
+package network
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Address is an IP address of a network interface
+type Address struct {
+	Interface string
+	IP        net.IP
+}
+
+func (a Address) String() string {
+	if a.IP == nil {
+		return ""
+	}
+	return a.IP.String()
+}
+
+// Lister returns the addresses of the network interfaces
+type Lister func() ([]Address, error)
+
+// SystemAddresses returns the addresses of the network interfaces that are up
+func SystemAddresses() ([]Address, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var result []Address
+	for _, i := range interfaces {
+		if i.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addresses, err := i.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addresses {
+			if n, ok := a.(*net.IPNet); ok {
+				result = append(result, Address{Interface: i.Name, IP: n.IP})
+			}
+		}
+	}
+	return result, nil
+}
+
+/**
+  Returns the address that is shown. Only IPv4 and global IPv6 addresses are used, the interfaces are ordered by
+  the prefixes of their names in 'priority' (e.g. "wlan", "eth"), interfaces that don't match follow. An interface
+  prefers its IPv4 address over its public and then its unique local IPv6 address.
+*/
+func Preferred(addresses []Address, priority []string) (Address, bool) {
+	var candidates []Address
+	for _, a := range addresses {
+		if addressRank(a.IP) >= 0 {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return Address{}, false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, pj := interfaceRank(candidates[i].Interface, priority), interfaceRank(candidates[j].Interface, priority)
+		if pi != pj {
+			return pi < pj
+		}
+		if candidates[i].Interface != candidates[j].Interface {
+			return candidates[i].Interface < candidates[j].Interface
+		}
+		return addressRank(candidates[i].IP) < addressRank(candidates[j].IP)
+	})
+	return candidates[0], true
+}
+
+// returns the rank of the address: 0 for IPv4, 1 for public IPv6, 2 for unique local IPv6 and -1 for addresses
+// that aren't shown (loopback, link local, ...)
+func addressRank(ip net.IP) int {
+	switch {
+	case ip == nil || !ip.IsGlobalUnicast():
+		return -1
+	case ip.To4() != nil:
+		return 0
+	case ip[0]&0xfe == 0xfc:
+		return 2
+	}
+	return 1
+}
+
+// returns the index of the first prefix that matches the interface or the number of prefixes
+func interfaceRank(name string, priority []string) int {
+	for i, p := range priority {
+		if strings.HasPrefix(name, p) {
+			return i
+		}
+	}
+	return len(priority)
+}
+
+// Tracker polls the addresses of the network interfaces and reports the changes of the preferred address
+type Tracker struct {
+	list     Lister
+	priority []string
+	interval time.Duration
+	mutex    sync.Mutex
+	current  Address
+	all      []Address
+	changes  chan Address
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+/**
+  Starts polling the addresses with the given interval. The first address is reported immediately.
+*/
+func NewTracker(list Lister, priority []string, interval time.Duration) *Tracker {
+	if list == nil {
+		list = SystemAddresses
+	}
+	t := &Tracker{list: list, priority: priority, interval: interval, changes: make(chan Address, 1),
+		done: make(chan struct{})}
+	t.wg.Add(1)
+	go t.poll()
+	return t
+}
+
+// Changes returns the channel with the new preferred address. An empty address means, that there's none.
+func (t *Tracker) Changes() <-chan Address {
+	return t.changes
+}
+
+// Current returns the preferred address
+func (t *Tracker) Current() Address {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.current
+}
+
+// All returns all addresses found by the last poll
+func (t *Tracker) All() []Address {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.all
+}
+
+// Close stops polling and closes the channel
+func (t *Tracker) Close() {
+	close(t.done)
+	t.wg.Wait()
+	close(t.changes)
+}
+
+func (t *Tracker) poll() {
+	defer t.wg.Done()
+	first := true
+	for {
+		if addresses, err := t.list(); err == nil {
+			preferred, _ := Preferred(addresses, t.priority)
+			t.mutex.Lock()
+			changed := first || !preferred.IP.Equal(t.current.IP) || preferred.Interface != t.current.Interface
+			t.current = preferred
+			t.all = addresses
+			t.mutex.Unlock()
+			first = false
+			if changed {
+				select {
+				case t.changes <- preferred:
+				case <-t.done:
+					return
+				}
+			}
+		}
+		select {
+		case <-time.After(t.interval):
+		case <-t.done:
+			return
+		}
+	}
+}
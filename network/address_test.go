@@ -0,0 +1,79 @@
+package network
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func addr(iface, ip string) Address {
+	return Address{Interface: iface, IP: net.ParseIP(ip)}
+}
+
+func TestPreferred(t *testing.T) {
+	priority := []string{"wlan", "eth"}
+	tests := []struct {
+		name      string
+		addresses []Address
+		want      string
+	}{
+		{"none", []Address{addr("lo", "127.0.0.1"), addr("lo", "::1"), addr("wlan0", "fe80::1")}, ""},
+		{"ipv4", []Address{addr("lo", "127.0.0.1"), addr("eth0", "192.168.1.20")}, "192.168.1.20"},
+		{"priority", []Address{addr("eth0", "192.168.1.20"), addr("wlan0", "192.168.1.21")}, "192.168.1.21"},
+		{"unlisted interface last", []Address{addr("usb0", "10.0.0.2"), addr("eth0", "192.168.1.20")},
+			"192.168.1.20"},
+		{"ipv6 only", []Address{addr("wlan0", "fe80::1"), addr("wlan0", "fd00::5"), addr("wlan0", "2001:db8::5")},
+			"2001:db8::5"},
+		{"ipv4 before ipv6", []Address{addr("wlan0", "2001:db8::5"), addr("wlan0", "192.168.1.21")},
+			"192.168.1.21"},
+		{"interface before family", []Address{addr("eth0", "192.168.1.20"), addr("wlan0", "2001:db8::5")},
+			"2001:db8::5"},
+	}
+	for _, tt := range tests {
+		got, ok := Preferred(tt.addresses, priority)
+		if got.String() != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: got %q (%v), want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	var mutex sync.Mutex
+	addresses := []Address{addr("lo", "127.0.0.1"), addr("eth0", "192.168.1.20")}
+	list := func() ([]Address, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return addresses, nil
+	}
+	tracker := NewTracker(list, []string{"wlan", "eth"}, 10*time.Millisecond)
+	defer tracker.Close()
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case a := <-tracker.Changes():
+			if a.String() != want {
+				t.Fatalf("got %q, want %q", a, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change to %q", want)
+		}
+	}
+	expect("192.168.1.20")
+	if tracker.Current().String() != "192.168.1.20" || len(tracker.All()) != 2 {
+		t.Errorf("unexpected state %v %v", tracker.Current(), tracker.All())
+	}
+	mutex.Lock()
+	addresses = append(addresses, addr("wlan0", "2001:db8::5"))
+	mutex.Unlock()
+	expect("2001:db8::5")
+	mutex.Lock()
+	addresses = []Address{addr("lo", "127.0.0.1")}
+	mutex.Unlock()
+	expect("")
+	select {
+	case a := <-tracker.Changes():
+		t.Errorf("unexpected change to %q", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,301 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step is a step of the readiness check
+type Step string
+
+const (
+	Link  Step = "link"  // a network interface is up
+	Route Step = "route" // a default route exists
+	DNS   Step = "dns"   // the host name can be resolved
+	Probe Step = "probe" // the probe URL answers
+)
+
+// Steps are the steps of the readiness check in the order they are checked
+var Steps = []Step{Link, Route, DNS, Probe}
+
+// Status is the result of a readiness check
+type Status struct {
+	Online  bool
+	Passed  int   // number of steps that passed
+	Failed  Step  // the step that failed, empty when online
+	Err     error // the error of the failed step
+	Attempt int   // number of failed checks since the network went offline
+}
+
+func (s Status) String() string {
+	if s.Online {
+		return "online"
+	}
+	return fmt.Sprintf("%s failed (%d/%d, attempt %d): %v", s.Failed, s.Passed, len(Steps), s.Attempt, s.Err)
+}
+
+// Options configure the Monitor
+type Options struct {
+	SysRoot       string        // root of the sys filesystem with the link states; defaults to "/sys"
+	ProcRoot      string        // root of the proc filesystem with the routes; defaults to "/proc"
+	ProbeURL      string        // URL that must answer; the probe is skipped when empty
+	DNSHost       string        // host that is resolved; defaults to the host of the probe URL
+	Timeout       time.Duration // timeout of the DNS lookup and the probe; defaults to 5s
+	Interval      time.Duration // time between the checks while online; defaults to 30s
+	RetryInterval time.Duration // time between the checks while offline; defaults to 2s
+	Failures      int           // number of failed checks in a row until the network is offline; defaults to 2
+	// resolves the host; defaults to net.DefaultResolver
+	Resolve func(ctx context.Context, host string) error
+	Client  *http.Client // client for the probe; defaults to http.DefaultClient
+}
+
+// Monitor checks the readiness of the network periodically
+type Monitor struct {
+	opts   Options
+	status chan Status
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+/**
+  Starts checking the network. Every failed check is reported while the network is offline, so that the progress
+  can be shown. While online, only the change to offline is reported.
+*/
+func New(opts Options) *Monitor {
+	if opts.SysRoot == "" {
+		opts.SysRoot = "/sys"
+	}
+	if opts.ProcRoot == "" {
+		opts.ProcRoot = "/proc"
+	}
+	if opts.DNSHost == "" && opts.ProbeURL != "" {
+		if u, err := url.Parse(opts.ProbeURL); err == nil {
+			opts.DNSHost = u.Hostname()
+		}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 2 * time.Second
+	}
+	if opts.Failures <= 0 {
+		opts.Failures = 2
+	}
+	if opts.Resolve == nil {
+		opts.Resolve = func(ctx context.Context, host string) error {
+			_, err := net.DefaultResolver.LookupHost(ctx, host)
+			return err
+		}
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	m := &Monitor{opts: opts, status: make(chan Status), done: make(chan struct{})}
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Status returns the channel with the results of the checks
+func (m *Monitor) Status() <-chan Status {
+	return m.status
+}
+
+// Close stops the checks and closes the status channel
+func (m *Monitor) Close() {
+	close(m.done)
+	m.wg.Wait()
+	close(m.status)
+}
+
+func (m *Monitor) run() {
+	defer m.wg.Done()
+	online := false
+	failures := 0
+	attempt := 0
+	for {
+		s := m.Check()
+		interval := m.opts.RetryInterval
+		send := false
+		if s.Online {
+			failures = 0
+			attempt = 0
+			send = !online
+			online = true
+			interval = m.opts.Interval
+		} else {
+			failures++
+			// while online, a single failure doesn't make the network offline
+			if !online || failures >= m.opts.Failures {
+				attempt++
+				s.Attempt = attempt
+				online = false
+				send = true
+			}
+		}
+		if send {
+			select {
+			case m.status <- s:
+			case <-m.done:
+				return
+			}
+		}
+		select {
+		case <-time.After(interval):
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Check runs the steps of the readiness check in their order until one fails
+func (m *Monitor) Check() Status {
+	s := Status{}
+	for _, step := range Steps {
+		var err error
+		switch step {
+		case Link:
+			err = linkUp(m.opts.SysRoot)
+		case Route:
+			err = defaultRoute(m.opts.ProcRoot)
+		case DNS:
+			err = m.resolve()
+		case Probe:
+			err = m.probe()
+		}
+		if err != nil {
+			s.Failed = step
+			s.Err = err
+			return s
+		}
+		s.Passed++
+	}
+	s.Online = true
+	return s
+}
+
+func (m *Monitor) resolve() error {
+	if m.opts.DNSHost == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+	defer cancel()
+	return m.opts.Resolve(ctx, m.opts.DNSHost)
+}
+
+// requests the probe URL. Every HTTP answer is fine, because it shows that the server was reached.
+func (m *Monitor) probe() error {
+	if m.opts.ProbeURL == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.opts.ProbeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+	return nil
+}
+
+// returns an error if no network interface except loopback is up
+func linkUp(sysRoot string) error {
+	dir := filepath.Join(sysRoot, "class", "net")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() == "lo" {
+			continue
+		}
+		state := readTrimmed(filepath.Join(dir, e.Name(), "operstate"))
+		// some interfaces (e.g. tunnels) don't report their state, but their carrier
+		if state == "up" || (state == "unknown" && readTrimmed(filepath.Join(dir, e.Name(), "carrier")) == "1") {
+			return nil
+		}
+	}
+	return fmt.Errorf("no network interface is up")
+}
+
+func readTrimmed(fileName string) string {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// the flags of a route
+const (
+	routeUp     = 0x0001
+	routeReject = 0x0200
+)
+
+// returns an error if there's neither an IPv4 nor an IPv6 default route
+func defaultRoute(procRoot string) error {
+	// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+	if hasRoute(filepath.Join(procRoot, "net", "route"), func(f []string) (string, string, bool) {
+		if len(f) < 8 {
+			return "", "", false
+		}
+		return f[0], f[3], f[1] == "00000000" && f[7] == "00000000"
+	}) {
+		return nil
+	}
+	// Destination PrefixLength Source PrefixLength NextHop Metric RefCnt Use Flags Iface
+	if hasRoute(filepath.Join(procRoot, "net", "ipv6_route"), func(f []string) (string, string, bool) {
+		if len(f) < 10 {
+			return "", "", false
+		}
+		return f[9], f[8], f[0] == strings.Repeat("0", 32) && f[1] == "00"
+	}) {
+		return nil
+	}
+	return fmt.Errorf("no default route")
+}
+
+// returns true if the route file contains a default route that is up. 'parse' returns the interface, the
+// flags and whether it's a default route.
+func hasRoute(fileName string, parse func(fields []string) (string, string, bool)) bool {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return false
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		iface, flagsHex, isDefault := parse(strings.Fields(scanner.Text()))
+		if !isDefault || iface == "lo" {
+			continue
+		}
+		flags, err := strconv.ParseUint(flagsHex, 16, 32)
+		if err == nil && flags&routeUp != 0 && flags&routeReject == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const routeHeader = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n"
+
+// fakeNet is a fake sys and proc filesystem with a resolver that can be switched off
+type fakeNet struct {
+	t     *testing.T
+	root  string
+	mutex sync.Mutex
+	dns   bool
+}
+
+func newFakeNet(t *testing.T) *fakeNet {
+	n := &fakeNet{t: t, root: t.TempDir(), dns: true}
+	n.write("proc/net/route", routeHeader)
+	n.write("proc/net/ipv6_route", "")
+	n.write("sys/class/net/lo/operstate", "unknown\n")
+	n.write("sys/class/net/wlan0/operstate", "down\n")
+	return n
+}
+
+func (n *fakeNet) write(name, content string) {
+	fileName := filepath.Join(n.root, name)
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		n.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+		n.t.Fatal(err)
+	}
+}
+
+func (n *fakeNet) setDNS(ok bool) {
+	n.mutex.Lock()
+	n.dns = ok
+	n.mutex.Unlock()
+}
+
+func (n *fakeNet) options(probeURL string) Options {
+	return Options{
+		SysRoot:  filepath.Join(n.root, "sys"),
+		ProcRoot: filepath.Join(n.root, "proc"),
+		ProbeURL: probeURL,
+		DNSHost:  "radio.example.com",
+		Timeout:  time.Second,
+		Resolve: func(ctx context.Context, host string) error {
+			n.mutex.Lock()
+			defer n.mutex.Unlock()
+			if !n.dns {
+				return errors.New("no such host")
+			}
+			return nil
+		},
+		Interval:      10 * time.Millisecond,
+		RetryInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	n := newFakeNet(t)
+	m := &Monitor{opts: n.options(server.URL)}
+	m.opts.Client = http.DefaultClient
+
+	expect := func(failed Step, passed int) {
+		t.Helper()
+		s := m.Check()
+		if s.Failed != failed || s.Passed != passed || s.Online != (failed == "") {
+			t.Fatalf("got %v, want %s failed after %d steps", s, failed, passed)
+		}
+	}
+	expect(Link, 0)
+	n.write("sys/class/net/wlan0/operstate", "up\n")
+	expect(Route, 1)
+	// an unreachable route doesn't count
+	n.write("proc/net/ipv6_route", "00000000000000000000000000000000 00 00000000000000000000000000000000 00 "+
+		"00000000000000000000000000000000 ffffffff 00000001 00000001 00200200       lo\n")
+	expect(Route, 1)
+	n.write("proc/net/route", routeHeader+"wlan0\t0000A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\t0\t0\t0\n")
+	expect(Route, 1)
+	n.write("proc/net/route", routeHeader+"wlan0\t00000000\t0100A8C0\t0003\t0\t0\t0\t00000000\t0\t0\t0\n")
+	n.setDNS(false)
+	expect(DNS, 2)
+	n.setDNS(true)
+	expect("", 4)
+	server.Close()
+	expect(Probe, 3)
+
+	// IPv6 only
+	n.write("proc/net/route", routeHeader)
+	n.write("proc/net/ipv6_route", "00000000000000000000000000000000 00 00000000000000000000000000000000 00 "+
+		"fe800000000000000000000000000001 00000400 00000001 00000000 00000003    wlan0\n")
+	m.opts.ProbeURL = ""
+	expect("", 4)
+	// tunnels report their carrier
+	n.write("sys/class/net/wlan0/operstate", "down\n")
+	n.write("sys/class/net/tun0/operstate", "unknown\n")
+	n.write("sys/class/net/tun0/carrier", "1\n")
+	expect("", 4)
+}
+
+func TestMonitor(t *testing.T) {
+	n := newFakeNet(t)
+	m := New(n.options(""))
+	defer m.Close()
+	receive := func() Status {
+		t.Helper()
+		select {
+		case s := <-m.Status():
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("no status")
+		}
+		return Status{}
+	}
+
+	// every failed check is reported while offline
+	s := receive()
+	if s.Online || s.Failed != Link || s.Attempt != 1 {
+		t.Fatalf("unexpected status %v", s)
+	}
+	if s = receive(); s.Attempt != 2 {
+		t.Fatalf("unexpected status %v", s)
+	}
+	n.write("sys/class/net/wlan0/operstate", "up\n")
+	n.write("proc/net/route", routeHeader+"wlan0\t00000000\t0100A8C0\t0003\t0\t0\t0\t00000000\t0\t0\t0\n")
+	for s = receive(); !s.Online; s = receive() {
+	}
+	n.setDNS(false)
+	s = receive()
+	if s.Online || s.Failed != DNS || s.Attempt != 1 {
+		t.Fatalf("unexpected status %v", s)
+	}
+	n.setDNS(true)
+	for s = receive(); !s.Online; s = receive() {
+	}
+}
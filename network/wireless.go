@@ -0,0 +1,225 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signal is a line of /proc/net/wireless
+type Signal struct {
+	Interface string
+	Link      int // link quality, usually from 0 to 70
+	Level     int // signal level in dBm
+}
+
+/**
+  Reads the signal of the wireless interfaces from the file (usually /proc/net/wireless). An interface that isn't
+  associated with an access point is missing or has a link quality of 0.
+*/
+func ReadWireless(fileName string) ([]Signal, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	var signals []Signal
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// wlan0: 0000   54.  -56.  -256        0      0      0      0     11        0
+		kv := strings.SplitN(scanner.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields := strings.Fields(kv[1])
+		if len(fields) < 3 {
+			continue
+		}
+		link, err1 := strconv.ParseFloat(strings.TrimSuffix(fields[1], "."), 64)
+		level, err2 := strconv.ParseFloat(strings.TrimSuffix(fields[2], "."), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		signals = append(signals, Signal{Interface: strings.TrimSpace(kv[0]), Link: int(link), Level: int(level)})
+	}
+	return signals, scanner.Err()
+}
+
+// Bars returns the number of signal bars from 0 to 4
+func (s Signal) Bars() int {
+	switch {
+	case s.Link <= 0:
+		return 0
+	case s.Level >= -55:
+		return 4
+	case s.Level >= -66:
+		return 3
+	case s.Level >= -77:
+		return 2
+	case s.Level >= -88:
+		return 1
+	}
+	return 0
+}
+
+// Quality is the state of the Wi-Fi connection
+type Quality struct {
+	Interface  string
+	Connected  bool
+	Link       int  // link quality in percent
+	Level      int  // signal level in dBm
+	Bars       int  // signal bars from 0 to 4
+	Reconnects int  // number of reconnects within the window
+	Weak       bool // the signal is below the threshold for some time
+}
+
+func (q Quality) String() string {
+	if !q.Connected {
+		return fmt.Sprintf("%s not connected, %d reconnects", q.Interface, q.Reconnects)
+	}
+	return fmt.Sprintf("%s link %d%%, level %d dBm, %d bars, %d reconnects", q.Interface, q.Link, q.Level, q.Bars,
+		q.Reconnects)
+}
+
+// WirelessOptions configure the Wireless monitor
+type WirelessOptions struct {
+	FileName  string        // defaults to "/proc/net/wireless"
+	Interface string        // the interface; empty for the first interface in the file
+	Interval  time.Duration // time between the readings; defaults to 5s
+	Window    time.Duration // the time in which the reconnects are counted; defaults to 1h
+	Threshold int           // signal level in dBm, below which the signal is weak; defaults to -75
+	WeakTime  time.Duration // the time the signal must stay below the threshold to be weak; defaults to 1m
+}
+
+// Wireless reads the signal of the Wi-Fi interface periodically and counts the reconnects
+type Wireless struct {
+	opts       WirelessOptions
+	mutex      sync.Mutex
+	quality    Quality
+	reconnects []time.Time
+	below      time.Time // the signal is below the threshold since then; zero when it's above
+	seen       bool      // the interface was connected once
+	updates    chan Quality
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+/**
+  Starts reading the signal. A change of the bars, the connection, the reconnects or of the weak state is sent
+  to the channel Updates.
+*/
+func NewWireless(opts WirelessOptions) *Wireless {
+	if opts.FileName == "" {
+		opts.FileName = "/proc/net/wireless"
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Hour
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = -75
+	}
+	if opts.WeakTime <= 0 {
+		opts.WeakTime = time.Minute
+	}
+	w := &Wireless{opts: opts, updates: make(chan Quality, 1), done: make(chan struct{})}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// Updates returns the channel with the changes of the quality
+func (w *Wireless) Updates() <-chan Quality {
+	return w.updates
+}
+
+// Quality returns the last reading
+func (w *Wireless) Quality() Quality {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.quality
+}
+
+// Close stops reading and closes the channel
+func (w *Wireless) Close() {
+	close(w.done)
+	w.wg.Wait()
+	close(w.updates)
+}
+
+func (w *Wireless) run() {
+	defer w.wg.Done()
+	first := true
+	for {
+		if q, changed := w.update(time.Now()); changed || first {
+			first = false
+			select {
+			case w.updates <- q:
+			case <-w.done:
+				return
+			}
+		}
+		select {
+		case <-time.After(w.opts.Interval):
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// reads the file and returns the new quality and whether it has changed
+func (w *Wireless) update(now time.Time) (Quality, bool) {
+	signals, _ := ReadWireless(w.opts.FileName)
+	var signal Signal
+	found := false
+	for _, s := range signals {
+		if w.opts.Interface == "" || s.Interface == w.opts.Interface {
+			signal = s
+			found = true
+			break
+		}
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	old := w.quality
+	q := Quality{Interface: w.opts.Interface}
+	if found {
+		q.Interface = signal.Interface
+		q.Connected = signal.Link > 0
+		q.Level = signal.Level
+		q.Bars = signal.Bars()
+		q.Link = signal.Link * 100 / 70
+		if q.Link > 100 {
+			q.Link = 100
+		}
+	}
+	if q.Connected && !old.Connected && w.seen {
+		w.reconnects = append(w.reconnects, now)
+	}
+	if q.Connected {
+		w.seen = true
+	}
+	for len(w.reconnects) > 0 && now.Sub(w.reconnects[0]) > w.opts.Window {
+		w.reconnects = w.reconnects[1:]
+	}
+	q.Reconnects = len(w.reconnects)
+	if q.Connected && q.Level < w.opts.Threshold {
+		if w.below.IsZero() {
+			w.below = now
+		}
+		q.Weak = now.Sub(w.below) >= w.opts.WeakTime
+	} else {
+		w.below = time.Time{}
+	}
+	w.quality = q
+	changed := q.Connected != old.Connected || q.Bars != old.Bars || q.Reconnects != old.Reconnects ||
+		q.Weak != old.Weak || q.Interface != old.Interface
+	return q, changed
+}
@@ -0,0 +1,117 @@
+package network
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const wirelessHeader = `Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE
+ face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22
+`
+
+func writeWireless(t *testing.T, fileName, lines string) {
+	t.Helper()
+	if err := ioutil.WriteFile(fileName, []byte(wirelessHeader+lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadWireless(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "wireless")
+	writeWireless(t, fileName, " wlan0: 0000   54.  -56.  -256        0      0      0      0     11        0\n"+
+		"  wlan1: 0000   0   0   0        0      0      0      0      0        0\n")
+	signals, err := ReadWireless(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Signal{{"wlan0", 54, -56}, {"wlan1", 0, 0}}
+	if len(signals) != len(want) || signals[0] != want[0] || signals[1] != want[1] {
+		t.Errorf("got %v, want %v", signals, want)
+	}
+	if _, err = ReadWireless(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestBars(t *testing.T) {
+	tests := []struct {
+		signal Signal
+		bars   int
+	}{
+		{Signal{Link: 70, Level: -40}, 4},
+		{Signal{Link: 50, Level: -60}, 3},
+		{Signal{Link: 40, Level: -70}, 2},
+		{Signal{Link: 20, Level: -85}, 1},
+		{Signal{Link: 5, Level: -95}, 0},
+		{Signal{Link: 0, Level: -40}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.signal.Bars(); got != tt.bars {
+			t.Errorf("%v: got %d bars, want %d", tt.signal, got, tt.bars)
+		}
+	}
+}
+
+func TestWirelessUpdate(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "wireless")
+	w := &Wireless{opts: WirelessOptions{FileName: fileName, Interface: "wlan0", Window: time.Hour, Threshold: -75,
+		WeakTime: time.Minute}}
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	expect := func(q Quality, changed bool, wantChanged bool, connected bool, bars, reconnects int, weak bool) {
+		t.Helper()
+		if changed != wantChanged || q.Connected != connected || q.Bars != bars || q.Reconnects != reconnects ||
+			q.Weak != weak {
+			t.Fatalf("got %v (changed %v, weak %v)", q, changed, q.Weak)
+		}
+	}
+
+	writeWireless(t, fileName, " wlan0: 0000   54.  -56.  -256        0      0      0      0     11        0\n")
+	q, changed := w.update(now)
+	expect(q, changed, true, true, 3, 0, false)
+	if q.Link != 77 || q.Level != -56 || q.Interface != "wlan0" {
+		t.Errorf("unexpected quality %v", q)
+	}
+	q, changed = w.update(now.Add(5 * time.Second))
+	expect(q, changed, false, true, 3, 0, false)
+
+	// the interface loses the connection and reconnects
+	writeWireless(t, fileName, "")
+	q, changed = w.update(now.Add(10 * time.Second))
+	expect(q, changed, true, false, 0, 0, false)
+	writeWireless(t, fileName, " wlan0: 0000   30.  -80.  -256        0      0      0      0     11        0\n")
+	q, changed = w.update(now.Add(15 * time.Second))
+	expect(q, changed, true, true, 1, 1, false)
+
+	// weak after a minute below the threshold
+	q, changed = w.update(now.Add(50 * time.Second))
+	expect(q, changed, false, true, 1, 1, false)
+	q, changed = w.update(now.Add(75 * time.Second))
+	expect(q, changed, true, true, 1, 1, true)
+	writeWireless(t, fileName, " wlan0: 0000   60.  -50.  -256        0      0      0      0     11        0\n")
+	q, changed = w.update(now.Add(80 * time.Second))
+	expect(q, changed, true, true, 4, 1, false)
+
+	// the reconnect is forgotten after the window
+	q, changed = w.update(now.Add(time.Hour + 20*time.Second))
+	expect(q, changed, true, true, 4, 0, false)
+}
+
+func TestWireless(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "wireless")
+	writeWireless(t, fileName, " wlan0: 0000   54.  -56.  -256        0      0      0      0     11        0\n")
+	w := NewWireless(WirelessOptions{FileName: fileName, Interval: 10 * time.Millisecond})
+	defer w.Close()
+	select {
+	case q := <-w.Updates():
+		if !q.Connected || q.Interface != "wlan0" || q.Bars != 3 {
+			t.Errorf("unexpected quality %v", q)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update")
+	}
+	if w.Quality().Level != -56 {
+		t.Errorf("unexpected quality %v", w.Quality())
+	}
+}
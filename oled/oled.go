@@ -3,6 +3,7 @@ package oled
 import (
 	"image"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aluedtke7/piradio/display"
 	"github.com/antigloss/go/logger"
@@ -24,6 +25,8 @@ const (
 	cmdPrintline
 )
 
+var face = basicfont.Face7x13
+
 type oled struct {
 	dev          *ssd1306.Dev
 	img          *image1bit.VerticalLSB
@@ -41,12 +44,11 @@ type command struct {
 }
 
 func (o *oled) printLine(ofs int, text string) {
-	f := basicfont.Face7x13
 	lineOfs := 50 - ofs*16
 	drawer := font.Drawer{
 		Dst:  o.img,
 		Src:  &image.Uniform{image1bit.On},
-		Face: f,
+		Face: face,
 		Dot:  fixed.P(0, o.img.Bounds().Dy()-lineOfs),
 	}
 	drawer.DrawString(text)
@@ -162,6 +164,34 @@ func (o *oled) GetCharsPerLine() int {
 	return o.charsPerLine
 }
 
+func (o *oled) GetCapabilities() display.Capabilities {
+	c := display.Capabilities{
+		Lines:       numLines,
+		Columns:     o.charsPerLine,
+		Contrast:    true,
+		CustomChars: true, // everything is drawn pixel by pixel
+		HasGlyph:    hasGlyph,
+	}
+	if o.dev != nil {
+		c.Width = o.dev.Bounds().Dx()
+		c.Height = o.dev.Bounds().Dy()
+	}
+	return c
+}
+
+// checks if the font used contains a glyph for the given rune
+func hasGlyph(r rune) bool {
+	if r == utf8.RuneError {
+		return false
+	}
+	for _, rng := range face.Ranges {
+		if r >= rng.Low && r < rng.High {
+			return true
+		}
+	}
+	return false
+}
+
 /**
 Initializes the OLED Display and returns the maximum char count per line
 */
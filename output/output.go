@@ -0,0 +1,243 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aluedtke7/piradio/bluetooth"
+	"github.com/aluedtke7/piradio/config"
+)
+
+// the kinds of outputs, they have separate default volumes
+const (
+	Analog    = "analog"
+	Bluetooth = "bluetooth"
+)
+
+// a bluetooth address written with colons, underscores (PulseAudio, bluez-alsa) or '=' (mplayer's ALSA syntax)
+var addressRegex = regexp.MustCompile(`[0-9A-Fa-f]{2}([:_=][0-9A-Fa-f]{2}){5}`)
+
+// Output is an audio output of the player
+type Output struct {
+	Name   string // shown on the display
+	Driver string // audio output driver of mplayer (-ao), e.g. "alsa:device=hw=1.0" or "pulse::sink"
+}
+
+// Address returns the bluetooth address contained in the driver or an empty string
+func (o Output) Address() string {
+	a := addressRegex.FindString(o.Driver)
+	if a == "" {
+		return ""
+	}
+	return strings.ToUpper(strings.NewReplacer("_", ":", "=", ":").Replace(a))
+}
+
+// Kind returns Bluetooth for the output of a bluetooth device, otherwise Analog
+func (o Output) Kind() string {
+	if o.Address() != "" {
+		return Bluetooth
+	}
+	return Analog
+}
+
+// ID identifies the device of the output: the bluetooth address or "output:" and the name. It's used as a key in
+// the state file and therefore doesn't contain '=' like the driver.
+func (o Output) ID() string {
+	if a := o.Address(); a != "" {
+		return a
+	}
+	return "output:" + o.Name
+}
+
+/**
+  Returns the outputs of the section in the order of the file. Each entry is 'name = driver'.
+
+    [outputs]
+    speaker = alsa:device=hw=0.0
+    box = alsa:device=bluealsa
+*/
+func Load(section *config.Section) ([]Output, error) {
+	var outputs []Output
+	seen := map[string]bool{}
+	for _, e := range section.Entries {
+		if e.Value == "" {
+			return nil, fmt.Errorf("output %s has no driver", e.Key)
+		}
+		if seen[e.Key] {
+			return nil, fmt.Errorf("output %s is defined twice", e.Key)
+		}
+		seen[e.Key] = true
+		outputs = append(outputs, Output{Name: e.Key, Driver: e.Value})
+	}
+	return outputs, nil
+}
+
+// Checker checks if the device of an output is present
+type Checker struct {
+	Run       bluetooth.Runner  // runs 'pactl'; defaults to bluetooth.ExecRunner
+	ProcRoot  string            // defaults to "/proc"
+	Bluetooth func(string) bool // tells if the audio of the bluetooth device with the address is available
+}
+
+// Available returns true if the output can be used. An ALSA card must be listed in /proc/asound, a PulseAudio sink
+// by 'pactl'. The outputs of bluetooth devices are checked with the Bluetooth function.
+func (c Checker) Available(o Output) bool {
+	if a := o.Address(); a != "" {
+		return c.Bluetooth == nil || c.Bluetooth(a)
+	}
+	parts := strings.SplitN(o.Driver, ":", 3)
+	switch parts[0] {
+	case "alsa":
+		card := alsaCard(o.Driver)
+		if card == "" {
+			return true
+		}
+		root := c.ProcRoot
+		if root == "" {
+			root = "/proc"
+		}
+		if strings.Trim(card, "0123456789") == "" {
+			card = "card" + card
+		}
+		_, err := os.Stat(filepath.Join(root, "asound", card))
+		return err == nil
+	case "pulse":
+		if len(parts) < 3 || parts[2] == "" {
+			return true
+		}
+		run := c.Run
+		if run == nil {
+			run = bluetooth.ExecRunner
+		}
+		out, err := run("pactl", "list", "short", "sinks")
+		if err != nil {
+			return false
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Fields(line); len(fields) > 1 && fields[1] == parts[2] {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// returns the card of an ALSA driver like "alsa:device=hw=1.0" or "alsa:device=plughw=CARD=Device.DEV=0". The
+// device uses mplayer's syntax, where ':' is written as '=' and ',' as '.'.
+func alsaCard(driver string) string {
+	for _, opt := range strings.Split(strings.TrimPrefix(driver, "alsa:"), ":") {
+		if !strings.HasPrefix(opt, "device=") {
+			continue
+		}
+		device := strings.NewReplacer("=", ":", ".", ",").Replace(strings.TrimPrefix(opt, "device="))
+		kv := strings.SplitN(device, ":", 2)
+		if len(kv) < 2 || (kv[0] != "hw" && kv[0] != "plughw") {
+			return ""
+		}
+		card := strings.Split(kv[1], ",")[0]
+		return strings.TrimPrefix(card, "CARD:")
+	}
+	return ""
+}
+
+// Switcher keeps the output selected by the user. While the selected output isn't available, the first available
+// output in the configured order is used. It's safe for concurrent use.
+type Switcher struct {
+	outputs   []Output
+	available func(Output) bool
+	mutex     sync.Mutex
+	selected  int
+	active    int // -1 while no output is available
+}
+
+/**
+  Returns a switcher for the outputs. The first output is selected.
+*/
+func NewSwitcher(outputs []Output, available func(Output) bool) *Switcher {
+	s := &Switcher{outputs: outputs, available: available, active: -1}
+	s.Check()
+	return s
+}
+
+// Outputs returns the configured outputs
+func (s *Switcher) Outputs() []Output {
+	return s.outputs
+}
+
+// Active returns the output that is used
+func (s *Switcher) Active() (Output, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.active < 0 {
+		return Output{}, false
+	}
+	return s.outputs[s.active], true
+}
+
+// Check checks the outputs and returns true if the active output has changed
+func (s *Switcher) Check() bool {
+	avail := make([]bool, len(s.outputs))
+	for i, o := range s.outputs {
+		avail[i] = s.available(o)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.activate(avail)
+}
+
+// Next selects the next available output after the active one and returns true if the active output has changed
+func (s *Switcher) Next() bool {
+	avail := make([]bool, len(s.outputs))
+	for i, o := range s.outputs {
+		avail[i] = s.available(o)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for n := 1; n <= len(s.outputs); n++ {
+		i := (s.active + n + len(s.outputs)) % len(s.outputs)
+		if avail[i] {
+			s.selected = i
+			break
+		}
+	}
+	return s.activate(avail)
+}
+
+// Select selects the output with the given name and returns true if the active output has changed
+func (s *Switcher) Select(name string) (bool, error) {
+	for i, o := range s.outputs {
+		if o.Name == name {
+			if !s.available(o) {
+				return false, fmt.Errorf("output %s isn't available", name)
+			}
+			s.mutex.Lock()
+			s.selected = i
+			s.mutex.Unlock()
+			return s.Check(), nil
+		}
+	}
+	return false, fmt.Errorf("unknown output %s", name)
+}
+
+// makes the selected output or the first available output active; must be called with the lock held
+func (s *Switcher) activate(avail []bool) bool {
+	active := -1
+	if s.selected < len(avail) && avail[s.selected] {
+		active = s.selected
+	} else {
+		for i, ok := range avail {
+			if ok {
+				active = i
+				break
+			}
+		}
+	}
+	changed := active != s.active
+	s.active = active
+	return changed
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aluedtke7/piradio/config"
+)
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		driver  string
+		address string
+		kind    string
+		id      string
+	}{
+		{"alsa:device=hw=1.0", "", Analog, "output:x"},
+		{"alsa:device=bluealsa=DEV=aa=bb=cc=dd=ee=ff", "AA:BB:CC:DD:EE:FF", Bluetooth, "AA:BB:CC:DD:EE:FF"},
+		{"pulse::bluez_sink.AA_BB_CC_DD_EE_FF.a2dp_sink", "AA:BB:CC:DD:EE:FF", Bluetooth, "AA:BB:CC:DD:EE:FF"},
+		{"pulse", "", Analog, "output:x"},
+	}
+	for _, tt := range tests {
+		o := Output{Name: "x", Driver: tt.driver}
+		if o.Address() != tt.address || o.Kind() != tt.kind || o.ID() != tt.id {
+			t.Errorf("%s: got %q %q %q", tt.driver, o.Address(), o.Kind(), o.ID())
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c, err := config.Parse(strings.NewReader("[outputs]\nSpeaker = alsa:device=hw=0.0\nbox = pulse::sink\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := Load(c.Section("outputs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[0] != (Output{"speaker", "alsa:device=hw=0.0"}) || outputs[1].Name != "box" {
+		t.Errorf("unexpected outputs %v", outputs)
+	}
+	c, _ = config.Parse(strings.NewReader("[outputs]\nbox = pulse\nbox = alsa\n"))
+	if _, err = Load(c.Section("outputs")); err == nil {
+		t.Error("expected an error for a duplicate output")
+	}
+	c, _ = config.Parse(strings.NewReader("[outputs]\nbox =\n"))
+	if _, err = Load(c.Section("outputs")); err == nil {
+		t.Error("expected an error for a missing driver")
+	}
+}
+
+func TestAlsaCard(t *testing.T) {
+	tests := map[string]string{
+		"alsa":               "",
+		"alsa:device=hw=1.0": "1",
+		"alsa:noblock:device=plughw=CARD=Device.DEV=0": "Device",
+		"alsa:device=hw=Headphones":                    "Headphones",
+		"alsa:device=default":                          "",
+		"alsa:device=dmix":                             "",
+	}
+	for driver, want := range tests {
+		if got := alsaCard(driver); got != want {
+			t.Errorf("%s: got %q, want %q", driver, got, want)
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"card0", "Headphones"} {
+		if err := os.MkdirAll(filepath.Join(root, "asound", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pulseRunning := true
+	c := Checker{
+		Run: func(name string, args ...string) ([]byte, error) {
+			if !pulseRunning {
+				return nil, errors.New("connection refused")
+			}
+			return []byte("0\talsa_output.platform-bcm2835_audio.analog-stereo\tmodule-alsa-card.c\ts16le 2ch 44100Hz\tIDLE\n"), nil
+		},
+		ProcRoot:  root,
+		Bluetooth: func(address string) bool { return address == "AA:BB:CC:DD:EE:FF" },
+	}
+	tests := map[string]bool{
+		"alsa:device=hw=0.0":        true,
+		"alsa:device=hw=1.0":        false,
+		"alsa:device=hw=Headphones": true,
+		"alsa:device=hw=Device":     false,
+		"alsa":                      true,
+		"pulse::alsa_output.platform-bcm2835_audio.analog-stereo": true,
+		"pulse::alsa_output.usb":                                  false,
+		"pulse::bluez_sink.AA_BB_CC_DD_EE_FF.a2dp_sink":           true,
+		"pulse::bluez_sink.11_22_33_44_55_66.a2dp_sink":           false,
+		"oss": true,
+	}
+	for driver, want := range tests {
+		if got := c.Available(Output{Driver: driver}); got != want {
+			t.Errorf("%s: got %v, want %v", driver, got, want)
+		}
+	}
+	pulseRunning = false
+	if c.Available(Output{Driver: "pulse::alsa_output.platform-bcm2835_audio.analog-stereo"}) {
+		t.Error("sink available without pulse")
+	}
+}
+
+func TestSwitcher(t *testing.T) {
+	outputs := []Output{{"speaker", "alsa:device=hw=0.0"}, {"usb", "alsa:device=hw=1.0"}, {"box", "pulse::box"}}
+	avail := map[string]bool{"speaker": true, "usb": true, "box": false}
+	s := NewSwitcher(outputs, func(o Output) bool { return avail[o.Name] })
+	expect := func(name string) {
+		t.Helper()
+		o, ok := s.Active()
+		if name == "" {
+			if ok {
+				t.Fatalf("got active output %s, want none", o.Name)
+			}
+			return
+		}
+		if !ok || o.Name != name {
+			t.Fatalf("got active output %s (%v), want %s", o.Name, ok, name)
+		}
+	}
+	expect("speaker")
+	// box isn't available, so it's skipped
+	if !s.Next() {
+		t.Error("Next didn't change the output")
+	}
+	expect("usb")
+	s.Next()
+	expect("speaker")
+	if _, err := s.Select("box"); err == nil {
+		t.Error("selected an output that isn't available")
+	}
+	if _, err := s.Select("unknown"); err == nil {
+		t.Error("selected an unknown output")
+	}
+	avail["box"] = true
+	if changed, err := s.Select("box"); err != nil || !changed {
+		t.Errorf("Select: %v %v", changed, err)
+	}
+	expect("box")
+	// fail over to the first available output and back
+	avail["box"] = false
+	if !s.Check() {
+		t.Error("Check didn't detect the missing output")
+	}
+	expect("speaker")
+	if s.Check() {
+		t.Error("Check changed the output without a change")
+	}
+	avail["box"] = true
+	s.Check()
+	expect("box")
+	avail = map[string]bool{}
+	s.Check()
+	expect("")
+	s.Next()
+	expect("")
+}
@@ -6,34 +6,46 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"net"
-	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"os/user"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
+	"github.com/aluedtke7/piradio/bluetooth"
+	"github.com/aluedtke7/piradio/charset"
+	"github.com/aluedtke7/piradio/cleanup"
+	"github.com/aluedtke7/piradio/config"
 	"github.com/aluedtke7/piradio/debouncer"
 	"github.com/aluedtke7/piradio/display"
+	"github.com/aluedtke7/piradio/gesture"
+	"github.com/aluedtke7/piradio/headless"
+	"github.com/aluedtke7/piradio/input"
 	"github.com/aluedtke7/piradio/lcd"
+	"github.com/aluedtke7/piradio/network"
 	"github.com/aluedtke7/piradio/oled"
+	"github.com/aluedtke7/piradio/output"
+	"github.com/aluedtke7/piradio/provision"
+	"github.com/aluedtke7/piradio/selector"
+	"github.com/aluedtke7/piradio/splitter"
+	"github.com/aluedtke7/piradio/state"
+	"github.com/aluedtke7/piradio/titlecase"
+	"github.com/aluedtke7/piradio/translit"
+	"github.com/aluedtke7/piradio/wrap"
 
 	"github.com/antigloss/go/logger"
 	"periph.io/x/periph/conn/gpio"
-	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/host"
 )
 
 const (
-	debounceTime            = 100
+	buttonStableTime        = 30
 	debounceWriteToFileTime = 15
 	defVolumeAnalog         = "55"
 	defVolumeBluetooth      = "35"
@@ -47,21 +59,46 @@ var (
 	camelCasePtr        *bool
 	noisePtr            *bool
 	oledPtr             *bool
+	headlessPtr         *bool
 	noBluetoothPtr      *bool
 	backlightOffPtr     *bool
 	backlightOffTimePtr *int
 	scrollStationPtr    *bool
 	lcdDelayPtr         *int
 	scrollSpeedPtr      *int
+	translitLangPtr     *string
+	charsetPtr          *string
+	splitPtr            *string
+	splitStrategy       = splitter.ArtistTitle
+	titleLayoutPtr      *string
+	titleLayout         = wrap.Wrap
 	stations            []radioStation
 	stationIdx          = -1
-	btDevices           []string
+	btManager           bluetooth.Manager
+	btAudio             bluetooth.AudioDetector
+	btState             *bluetooth.State
+	pairing             *pairingMode
+	pairingMutex        = &sync.Mutex{}
+	debouncePairing     = debouncer.New(time.Minute, debouncer.Options{})
 	bitrate             string
 	volume              string
-	volumeAnalog        string
-	volumeBluetooth     string
+	volumes             = map[string]string{}
+	volumesMutex        = &sync.Mutex{}
+	btAddress           string
+	outputSwitcher      *output.Switcher
+	networkOffline      bool
+	networkMutex        = &sync.Mutex{}
+	offlineSince        time.Time
+	provisioner         *provision.Provisioner
+	provisionTimeout    time.Duration
+	provisionDuration   time.Duration
+	provisionURL        string
+	provisionTimer      *time.Timer
+	wifiQuality         network.Quality
 	muted               bool
 	charsPerLine        int
+	caps                display.Capabilities
+	layout              = newLayout(display.Capabilities{Lines: 4, Columns: 20})
 	command             *exec.Cmd
 	inPipe              io.WriteCloser
 	outPipe             io.ReadCloser
@@ -69,18 +106,80 @@ var (
 	ipAddress           string
 	homePath            string
 	currentStation      string
-	debounceWrite       func(f func())
-	debounceBacklight   func(f func())
+	debounceWrite       *debouncer.Debouncer
+	debounceBacklight   *debouncer.Debouncer
 	stationMutex        = &sync.Mutex{}
-	charMap             = map[string]string{"'": "'", "´": "'", "á": "a", "é": "e", "ê": "e", "è": "e", "í": "i", "à": "a",
-		"ä": "ae", "Ä": "Ae", "ö": "oe", "Ö": "Oe", "ü": "ue", "Ü": "Ue", "ß": "ss", "…": "...", "Ó": "O", "ó": "o",
-		"õ": "o", "ñ": "n", "ó": "o", "ø": "o", "É": "E"}
+	transliterator      = translit.New("de")
+	noiseRules          = cleanup.Default()
+	caser               = titlecase.New(titlecase.Options{RomanNumerals: true})
+	settings            = config.New()
+	actions             map[string]func()
+	gestureActions      = map[string]string{
+		"next.short": "next", "prev.short": "prev", "up.short": "volumeUp", "down.short": "volumeDown",
+		"mute.short": "mute", "next.long": "next10", "prev.long": "prev10", "mute.long": "backlightTimeout",
+		"mute.hold": "shutdown", "encoder.short": "mute", "encoder.long": "encoderMode", "next+prev.combo": "pairing",
+		"down+up.combo": "provisioning",
+	}
+	encoderTunesStations bool
+	// the default pins of the buttons, the push switch of the rotary encoder is disabled by default
+	defaultButtonPins = []struct{ name, pin string }{
+		{"next", "GPIO5"}, {"prev", "GPIO6"}, {"up", "GPIO19"}, {"down", "GPIO26"}, {"mute", "GPIO16"}, {"encoder", "none"},
+	}
+	// the default actions of the keys of a remote control (lircd key names in lowercase letters)
+	lircKeyActions = withDigits(map[string]string{
+		"key_next": "next", "key_channelup": "next", "key_previous": "prev", "key_channeldown": "prev",
+		"key_volumeup": "volumeUp", "key_volumedown": "volumeDown", "key_mute": "mute", "key_ok": "confirm",
+		"key_exit": "cancel",
+	}, "key_")
+	// the default actions of the keys of keyboards and other input devices
+	keyboardKeyActions = withDigits(map[string]string{
+		"key_nextsong": "next", "key_previoussong": "prev", "key_next": "next", "key_previous": "prev",
+		"key_right": "next", "key_left": "prev", "key_volumeup": "volumeUp", "key_volumedown": "volumeDown",
+		"key_up": "volumeUp", "key_down": "volumeDown", "key_kpplus": "volumeUp", "key_kpminus": "volumeDown",
+		"key_mute": "mute", "key_playpause": "mute", "key_enter": "confirm", "key_kpenter": "confirm",
+		"key_esc": "cancel", "key_backspace": "cancel",
+	}, "key_", "key_kp", "key_numeric_")
+	// actions that are repeated while a key of the remote control or keyboard is held
+	repeatableActions = map[string]bool{"volumeUp": true, "volumeDown": true}
+	stationSelector   *selector.Selector
 )
 
-// holds a Radio Station name and url
+// state of the bluetooth pairing mode
+type pairingMode struct {
+	candidates []bluetooth.Device
+	idx        int
+	busy       bool // scanning or pairing
+}
+
+// holds a Radio Station name and url and the optional settings of the station
 type radioStation struct {
-	name string
-	url  string
+	name    string
+	url     string
+	charset string            // fallback charset for metadata that isn't valid UTF-8
+	split   splitter.Strategy // how the StreamTitle is split into artist and title
+}
+
+// assigns the displayed information to the lines of the display. A value of -1 means, that the information is
+// not shown. When artist and title share the same line, both are shown together separated by a hyphen.
+type screenLayout struct {
+	station int
+	artist  int
+	title   int
+	status  int
+}
+
+// returns the layout that fits best to the number of lines the display provides
+func newLayout(c display.Capabilities) screenLayout {
+	switch {
+	case c.Lines >= 4:
+		return screenLayout{station: 0, artist: 1, title: 2, status: c.Lines - 1}
+	case c.Lines == 3:
+		return screenLayout{station: 0, artist: 1, title: 1, status: 2}
+	case c.Lines == 2:
+		return screenLayout{station: -1, artist: 0, title: 0, status: 1}
+	default:
+		return screenLayout{station: -1, artist: 0, title: 0, status: -1}
+	}
 }
 
 // helper for error checking
@@ -91,36 +190,36 @@ func check(err error) {
 	}
 }
 
-// logs the ipv4 addresses found and stores the first non localhost addresses in variable 'ipAddress'
-func logNetworkInterfaces() {
-	interfaces, err := net.Interfaces()
-	if err != nil {
-		logger.Error(err.Error())
-		return
+// tracks the ip address that is shown while the first station is played. The interfaces are preferred in the
+// order of the setting 'interfaces' in the section 'network' of the config file (prefixes of their names).
+func trackAddress(section *config.Section) {
+	priority := section.List("interfaces")
+	if len(priority) == 0 {
+		priority = []string{"wlan", "eth"}
 	}
-	reg := regexp.MustCompile("^((25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])\\.){3}(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])")
-	for _, i := range interfaces {
-		byName, err := net.InterfaceByName(i.Name)
-		if err != nil {
-			logger.Warn(err.Error())
-		}
-		err = nil
-		addresses, err := byName.Addrs()
-		for _, v := range addresses {
-			ipv4 := v.String()
-			if reg.MatchString(ipv4) {
-				logger.Trace(ipv4)
-				if strings.Index(ipv4, "127.0.") != 0 {
-					idx := strings.Index(ipv4, "/")
-					if idx > 0 {
-						ipAddress = ipv4[0:idx]
-					} else {
-						ipAddress = ipv4
-					}
-				}
+	tracker := network.NewTracker(nil, priority, section.Duration("addressInterval", 10*time.Second))
+	go func() {
+		for a := range tracker.Changes() {
+			for _, all := range tracker.All() {
+				logger.Tracef("%s %s", all.Interface, all)
+			}
+			logger.Infof("IP address: %s %s", a.Interface, a)
+			networkMutex.Lock()
+			ipAddress = a.String()
+			networkMutex.Unlock()
+			if stationIdx == 0 {
+				showAddress()
 			}
 		}
-	}
+	}()
+}
+
+// shows the ip address in the status line. Long (IPv6) addresses are scrolled.
+func showAddress() {
+	networkMutex.Lock()
+	address := ipAddress
+	networkMutex.Unlock()
+	printLine(layout.status, address, len(address) > caps.Columns)
 }
 
 // checks if a given string contains only lowercase or special characters. Is used for the conversion to camel case.
@@ -135,19 +234,20 @@ func isOnlyLowerCase(text string) bool {
 	return true
 }
 
-// removes characters/runes that cannot be displayed on the LCD/OLED. These displays can only display ascii characters.
-// Via the 'charMap' the best possible translation is made. When the flag 'camelCase' is set to true, all non-only
-// lowercase strings will be converted to camel case format.
+// removes characters/runes that cannot be displayed on the LCD/OLED. Which characters can be displayed is defined by
+// the capabilities of the display (usually only ascii characters). For all other runes the best possible translation
+// is made via the 'transliterator'. When the flag 'camelCase' is set to true, all non-only lowercase strings will be
+// converted to title case format by the 'caser' (handles acronyms, small words, roman numerals etc.).
 func beautify(text string) string {
 	var b strings.Builder
-	for _, runeValue := range text {
-		s := charMap[string(runeValue)]
-		if s == "" {
-			if runeValue < 32 || runeValue > 126 {
-				logger.Trace("Illegal rune:", runeValue, string(runeValue))
-			} else {
-				b.WriteRune(runeValue)
-			}
+	for _, runeValue := range translit.Normalize(text) {
+		if caps.CanShow(runeValue) {
+			b.WriteRune(runeValue)
+			continue
+		}
+		s, ok := transliterator.Rune(runeValue)
+		if !ok {
+			logger.Trace("Illegal rune:", runeValue, string(runeValue))
 		} else {
 			b.WriteString(s)
 		}
@@ -155,28 +255,38 @@ func beautify(text string) string {
 	text = b.String()
 	if *camelCasePtr {
 		if !isOnlyLowerCase(text) {
-			cct := strings.Title(strings.ToLower(text))
-			idx := strings.Index(cct, "'")
-			if idx > 0 && idx < len(cct)-1 {
-				cct = cct[:idx+1] + strings.ToLower(string(cct[idx+1])) + cct[idx+2:]
-			}
-			return cct
+			return caser.Title(text)
 		}
 	}
 	return text
 }
 
 func printLine(line int, text string, scroll bool, doNotBeautify ...bool) {
+	if line < 0 || line >= caps.Lines {
+		return
+	}
 	t := strings.TrimSpace(text)
 	if len(doNotBeautify) < 1 {
 		t = beautify(t)
 	}
-	if line == 2 && *noisePtr {
-		t = removeNoise(t)
-	}
 	disp.PrintLine(line, t, scroll)
 }
 
+// shows artist and title on the lines between the artist and the title line of the layout. Depending on the
+// flag 'titleLayout' long texts are wrapped on word boundaries across these lines or they are scrolled. An empty
+// artist means, that the title couldn't be split.
+func showTitle(artist string, title string) {
+	if layout.artist < 0 {
+		return
+	}
+	artist = beautify(strings.TrimSpace(artist))
+	title = beautify(strings.TrimSpace(title))
+	lines := wrap.Arrange(titleLayout, artist, title, charsPerLine, layout.title-layout.artist+1)
+	for i, l := range lines {
+		printLine(layout.artist+i, l.Text, l.Scroll, true)
+	}
+}
+
 func fileExists(name string) bool {
 	if _, err := os.Stat(name); err != nil {
 		if os.IsNotExist(err) {
@@ -186,6 +296,18 @@ func fileExists(name string) bool {
 	return true
 }
 
+// loads the optional configuration file. Without the file, all settings have their default values.
+func loadConfig(fileName string) *config.Config {
+	if fileExists(fileName) {
+		c, err := config.Load(fileName)
+		if err == nil {
+			return c
+		}
+		logger.Errorf("Couldn't load configuration from %s: %s", fileName, err)
+	}
+	return config.New()
+}
+
 func getHomeDir() string {
 	usr, err := user.Current()
 	if err != nil {
@@ -194,43 +316,72 @@ func getHomeDir() string {
 	return usr.HomeDir
 }
 
-// returns the index of the last used station and the volume levels
-func getStationAndVolumes() (idx int, volAnalog string, volBt string) {
+// returns the index of the last used station and the volume levels of the output devices
+func getStationAndVolumes() (idx int, vols map[string]string) {
 	fileName := filepath.Join(homePath, "last_values")
-	content, err := ioutil.ReadFile(fileName)
-	if err != nil {
-		idx = 0
-		volAnalog = defVolumeAnalog
-		volBt = defVolumeBluetooth
-	} else {
-		contentArr := strings.Split(strings.Trim(string(content), " "), "\n")
-		if len(contentArr) > 0 {
-			idx, err = strconv.Atoi(contentArr[0])
-		}
-		if len(contentArr) > 1 && len(contentArr[1]) > 0 {
-			volAnalog = contentArr[1]
-		} else {
-			volAnalog = defVolumeAnalog
-		}
-		if len(contentArr) > 2 && len(contentArr[2]) > 0 {
-			volBt = contentArr[2]
-		} else {
-			volBt = defVolumeBluetooth
-		}
+	st, err := state.Load(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warnf("Error reading file %s : %s", fileName, err)
 	}
-	logger.Trace("getStationAndVolumes: %d %s %s", idx, volAnalog, volBt)
-	return idx - 1, volAnalog, volBt
+	logger.Tracef("getStationAndVolumes: %d %v", st.Station, st.Volumes)
+	return st.Station - 1, st.Volumes
 }
 
 // saves the index of the actual station index and the volumes levels
 func saveStationAndVolumes() {
 	fileName := filepath.Join(homePath, "last_values")
-	var s = strconv.Itoa(stationIdx) + "\n" + volumeAnalog + "\n" + volumeBluetooth
-	err := ioutil.WriteFile(fileName, []byte(s), 0644)
+	volumesMutex.Lock()
+	st := state.State{Station: stationIdx, Volumes: make(map[string]string, len(volumes))}
+	for output, v := range volumes {
+		st.Volumes[output] = v
+	}
+	volumesMutex.Unlock()
+	err := st.Save(fileName)
 	if err != nil {
-		logger.Warn("Error writing file %s : %s", fileName, err)
+		logger.Warnf("Error writing file %s : %s", fileName, err)
 	}
-	logger.Trace("saveStationAndVolumes: %d %s %s", stationIdx, volumeAnalog, volumeBluetooth)
+	logger.Tracef("saveStationAndVolumes: %d %v", st.Station, st.Volumes)
+}
+
+// returns the active output device and its kind. A bluetooth device is identified by its address.
+func currentOutput() (output string, kind string) {
+	if outputSwitcher != nil {
+		if o, ok := outputSwitcher.Active(); ok {
+			return o.ID(), o.Kind()
+		}
+	}
+	if bluetoothConnected && btAddress != "" {
+		return btAddress, state.Bluetooth
+	}
+	if bluetoothConnected {
+		return state.Bluetooth, state.Bluetooth
+	}
+	return state.Analog, state.Analog
+}
+
+// returns the volume of the output device. A device that has no volume yet gets the last volume used with a
+// device of the same kind or the default volume of the kind.
+func volumeFor(output, kind string) string {
+	volumesMutex.Lock()
+	defer volumesMutex.Unlock()
+	if v, ok := volumes[output]; ok {
+		return v
+	}
+	if v, ok := volumes[kind]; ok {
+		return v
+	}
+	if kind == state.Bluetooth {
+		return defVolumeBluetooth
+	}
+	return defVolumeAnalog
+}
+
+// remembers the volume of the output device and as the last volume of its kind
+func rememberVolume(output, kind, v string) {
+	volumesMutex.Lock()
+	volumes[output] = v
+	volumes[kind] = v
+	volumesMutex.Unlock()
 }
 
 // loads the list with radio stations or creates a default list
@@ -248,9 +399,13 @@ func loadStations(fileName string) []radioStation {
 		for scanner.Scan() {
 			line := strings.Trim(scanner.Text(), "\n\r")
 			items := strings.Split(line, ",")
-			if len(items) == 2 {
-				stations = append(stations,
-					radioStation{strconv.Itoa(nr) + " " + strings.TrimSpace(items[0]), strings.TrimSpace(items[1])})
+			if len(items) >= 2 {
+				station := radioStation{
+					name: strconv.Itoa(nr) + " " + strings.TrimSpace(items[0]),
+					url:  strings.TrimSpace(items[1]),
+				}
+				parseStationOptions(&station, items[2:])
+				stations = append(stations, station)
 				nr++
 			}
 		}
@@ -258,54 +413,289 @@ func loadStations(fileName string) []radioStation {
 	}
 	if len(stations) == 0 {
 		stations = append(stations,
-			radioStation{"RadioHH", "http://stream.radiohamburg.de/rhh-live/mp3-192/linkradiohamburgde"})
+			radioStation{name: "RadioHH", url: "http://stream.radiohamburg.de/rhh-live/mp3-192/linkradiohamburgde"})
 		stations = append(stations,
-			radioStation{"Jazz Radio", "http://jazzradio.ice.infomaniak.ch/jazzradio-high.mp3"})
+			radioStation{name: "Jazz Radio", url: "http://jazzradio.ice.infomaniak.ch/jazzradio-high.mp3"})
 		stations = append(stations,
-			radioStation{"M1.FM Chillout", "http://tuner.m1.fm/chillout.mp3"})
+			radioStation{name: "M1.FM Chillout", url: "http://tuner.m1.fm/chillout.mp3"})
 	}
 	return stations
 }
 
+// parses the optional settings of a station in the form 'key=value' (e.g. 'charset=windows-1251')
+func parseStationOptions(station *radioStation, options []string) {
+	for _, option := range options {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 {
+			logger.Warnf("Invalid option for station %s: %s", station.name, option)
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+		switch key {
+		case "charset":
+			if _, err := charset.Lookup(value); err != nil {
+				logger.Warnf("Unknown charset for station %s: %s", station.name, value)
+				continue
+			}
+			station.charset = value
+		case "split":
+			strategy, err := splitter.Parse(value)
+			if err != nil {
+				logger.Warnf("Invalid split strategy for station %s: %s", station.name, value)
+				continue
+			}
+			station.split = strategy
+		default:
+			logger.Warnf("Unknown option for station %s: %s", station.name, option)
+		}
+	}
+}
+
+// splits the StreamTitle into artist and title with the strategy of the actual station or the global strategy
+func splitTitle(title string) (string, string, bool) {
+	strategy := splitStrategy
+	if stationIdx >= 0 && stationIdx < len(stations) && stations[stationIdx].split != "" {
+		strategy = stations[stationIdx].split
+	}
+	return splitter.Split(strategy, title)
+}
+
+// converts mplayer output that isn't valid UTF-8 with the charset of the actual station or the global fallback charset
+func decodeOutput(line string) string {
+	cs := *charsetPtr
+	if stationIdx >= 0 && stationIdx < len(stations) && stations[stationIdx].charset != "" {
+		cs = stations[stationIdx].charset
+	}
+	return charset.Decode(line, cs)
+}
+
+// prints the bitrate left aligned and the volume right aligned, so that the line is completely filled
 func printBitrateVolume(lineNum int, bitrate string, volume string, muted bool) {
-	var s string
+	printLine(lineNum, bitrateVolumeText(bitrate, volume, muted), false, true)
+}
+
+// returns the status line with the bitrate, the Wi-Fi signal and the volume. When the line is too short, the
+// reconnects are left out of the signal and the bitrate is shortened.
+func bitrateVolumeText(bitrate string, volume string, muted bool) string {
 	if muted {
 		volume = "-mute-"
 	}
-	if charsPerLine < 20 {
-		s = fmt.Sprintf("%-10v%8v", bitrate, volume)
+	bitrateWidth := 10
+	if caps.Columns < 2*bitrateWidth-4 {
+		bitrateWidth = caps.Columns / 2
+	}
+	signal := signalText()
+	if signal != "" {
+		// the room for the bitrate and the signal, which are separated by at least one space from each other and
+		// from the volume
+		room := caps.Columns - utf8.RuneCountInString(volume) - 1
+		if utf8.RuneCountInString(bitrate)+1+utf8.RuneCountInString(signal) > room {
+			signal = strings.SplitN(signal, "!", 2)[0]
+		}
+		if w := room - utf8.RuneCountInString(signal); w < 0 {
+			signal = ""
+		} else if w < bitrateWidth {
+			bitrateWidth = w
+		}
+	}
+	if signal != "" {
+		bitrate = shorten(bitrate, bitrateWidth-1)
 	} else {
-		s = fmt.Sprintf("%-10v%10v", bitrate, volume)
+		bitrate = shorten(bitrate, bitrateWidth)
 	}
-	printLine(lineNum, s, false, true)
+	return fmt.Sprintf("%-*v%s%*v", bitrateWidth, bitrate, signal,
+		caps.Columns-bitrateWidth-utf8.RuneCountInString(signal), volume)
 }
 
-func isConnected(url string) bool {
-	_, err := http.Get(url)
-	if err != nil {
-		return false
+// shortens the bitrate to the width: the unit is left out first (e.g. "128 kbit" becomes "128")
+func shorten(bitrate string, width int) string {
+	if utf8.RuneCountInString(bitrate) <= width {
+		return bitrate
 	}
-	return true
+	if fields := strings.Fields(bitrate); len(fields) > 0 {
+		bitrate = fields[0]
+	}
+	if r := []rune(bitrate); len(r) > width {
+		if width < 0 {
+			return ""
+		}
+		bitrate = string(r[:width])
+	}
+	return bitrate
 }
 
-// does everything to stop the running mplayer and start a new instance with the actual station url
-func newStation() {
-	disp.Clear()
-	logger.Trace("New station: %s", stations[stationIdx].name)
-	printLine(0, "-> "+stations[stationIdx].name, false)
-	printLine(1, "", false)
-	printLine(2, "", false)
-	if stationIdx == 0 {
-		printLine(3, ipAddress, false)
-	} else {
-		printLine(3, time.Now().Format("15:04:05  02.01.06"), false)
+// the glyphs of the Wi-Fi signal from 0 to 4 bars
+var signalGlyphs = []rune{'▁', '▂', '▄', '▆', '█'}
+
+// returns the Wi-Fi signal for the status line: the bars and the number of recent reconnects (e.g. "▆!2"). When
+// the display can't show the glyphs, the bars are written as a number (e.g. "W3!2").
+func signalText() string {
+	networkMutex.Lock()
+	q := wifiQuality
+	networkMutex.Unlock()
+	if q.Interface == "" {
+		return ""
+	}
+	glyphs := true
+	for _, r := range signalGlyphs {
+		glyphs = glyphs && caps.CanShow(r)
+	}
+	var text string
+	switch {
+	case !q.Connected && glyphs:
+		text = "-"
+	case !q.Connected:
+		text = "W-"
+	case glyphs:
+		text = string(signalGlyphs[q.Bars])
+	default:
+		text = "W" + strconv.Itoa(q.Bars)
+	}
+	if q.Reconnects > 0 {
+		text += "!" + strconv.Itoa(q.Reconnects)
+	}
+	return text
+}
+
+// reads the Wi-Fi signal periodically. The settings are read from the section 'wifi' of the config file.
+func startWireless(section *config.Section) {
+	if !section.Bool("enabled", true) {
+		return
+	}
+	w := network.NewWireless(network.WirelessOptions{
+		Interface: section.String("interface", ""),
+		Interval:  section.Duration("interval", 5*time.Second),
+		Window:    section.Duration("window", time.Hour),
+		Threshold: section.Int("threshold", -75),
+		WeakTime:  section.Duration("weakTime", time.Minute),
+	})
+	go func() {
+		for q := range w.Updates() {
+			networkMutex.Lock()
+			old := wifiQuality
+			wifiQuality = q
+			networkMutex.Unlock()
+			switch {
+			case q.Interface == "":
+				continue
+			case q.Weak && !old.Weak:
+				logger.Warnf("Wi-Fi signal is weak: %s", q)
+			case q.Connected != old.Connected:
+				logger.Infof("Wi-Fi: %s", q)
+			default:
+				logger.Tracef("Wi-Fi: %s", q)
+			}
+			if *debug {
+				fmt.Println("Wi-Fi: " + q.String())
+			}
+			if bitrate != "" {
+				printBitrateVolume(layout.status, bitrate, volume, muted)
+			}
+		}
+	}()
+}
+
+// starts the monitor for the network. The settings are read from the section 'network' of the config file.
+// By default only the link and the route are checked: the DNS check and the probe need 'dnsHost' or 'probeUrl'.
+func startNetworkMonitor(section *config.Section) *network.Monitor {
+	return network.New(network.Options{
+		ProbeURL:      section.String("probeUrl", ""),
+		DNSHost:       section.String("dnsHost", ""),
+		Timeout:       section.Duration("timeout", 5*time.Second),
+		Interval:      section.Duration("interval", 30*time.Second),
+		RetryInterval: section.Duration("retryInterval", 2*time.Second),
+		Failures:      section.Int("failures", 2),
+	})
+}
+
+// shows the progress of the network checks until the network is ready
+func waitForNetwork(netStatus <-chan network.Status) {
+	for s := range netStatus {
+		if s.Online {
+			logger.Info("Network is ready")
+			checkProvisioning(s)
+			return
+		}
+		logger.Tracef("Network not ready: %s", s)
+		showNetworkProgress(s)
 	}
+}
+
+// shows the failed step of the network check, the number of passed steps and the attempt (e.g. "dns 2/4 #3")
+func showNetworkProgress(s network.Status) {
+	checkProvisioning(s)
+	if provisioner.Active() {
+		return
+	}
+	printLine(layout.artist, "Waiting for network", false)
+	printLine(layout.status, fmt.Sprintf("%s %d/%d #%d", s.Failed, s.Passed, len(network.Steps), s.Attempt), false)
+}
+
+// pauses the playback while the network is offline and resumes it, when the network is back
+func followNetwork(netStatus <-chan network.Status) {
+	for s := range netStatus {
+		if s.Online {
+			checkProvisioning(s)
+			logger.Info("Network is back, resuming playback")
+			stationMutex.Lock()
+			setNetworkOffline(false)
+			newStation()
+			stationMutex.Unlock()
+			continue
+		}
+		if s.Attempt == 1 {
+			logger.Warnf("Network is offline, pausing playback: %s", s)
+			stationMutex.Lock()
+			setNetworkOffline(true)
+			stopMplayer()
+			stationMutex.Unlock()
+			printLine(layout.title, "", false)
+		}
+		showNetworkProgress(s)
+	}
+}
+
+func setNetworkOffline(offline bool) {
+	networkMutex.Lock()
+	networkOffline = offline
+	networkMutex.Unlock()
+}
+
+func isNetworkOffline() bool {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	return networkOffline
+}
+
+// stops the running mplayer instance
+func stopMplayer() {
 	if inPipe != nil {
 		_, _ = inPipe.Write([]byte("q"))
 		_ = inPipe.Close()
 		_ = outPipe.Close()
 		_ = command.Wait()
 	}
+}
+
+// does everything to stop the running mplayer and start a new instance with the actual station url
+func newStation() {
+	disp.Clear()
+	logger.Trace("New station: %s", stations[stationIdx].name)
+	if layout.station >= 0 {
+		printLine(layout.station, "-> "+stations[stationIdx].name, false)
+		printLine(layout.artist, "", false)
+		printLine(layout.title, "", false)
+	} else {
+		// small displays: the station name is shown until the first title arrives
+		printLine(layout.artist, "-> "+stations[stationIdx].name, false)
+	}
+	if stationIdx == 0 {
+		showAddress()
+	} else {
+		printLine(layout.status, time.Now().Format("15:04:05  02.01.06"), false)
+	}
+	stopMplayer()
 	for {
 		if readyForMplayer {
 			break
@@ -313,15 +703,20 @@ func newStation() {
 		logger.Trace("Waiting for 'readyForMplayer'...")
 		time.Sleep(time.Second)
 	}
-	if bluetoothConnected {
-		logger.Trace("Using BT volume " + volumeBluetooth)
-		volume = vol2VolString(volumeBluetooth)
-		command = exec.Command("mplayer", "-quiet", "-volume", volumeBluetooth, stations[stationIdx].url)
-	} else {
-		logger.Trace("Using Analog volume " + volumeAnalog)
-		volume = vol2VolString(volumeAnalog)
-		command = exec.Command("mplayer", "-quiet", "-volume", volumeAnalog, stations[stationIdx].url)
+	device, kind := currentOutput()
+	v := volumeFor(device, kind)
+	logger.Tracef("Using volume %s for %s", v, device)
+	volume = vol2VolString(v)
+	args := []string{"-quiet", "-volume", v}
+	if outputSwitcher != nil {
+		if o, ok := outputSwitcher.Active(); ok {
+			logger.Tracef("Using output %s (%s)", o.Name, o.Driver)
+			args = append(args, "-ao", o.Driver)
+		} else {
+			logger.Warn("No output available, using the default output")
+		}
 	}
+	command = exec.Command("mplayer", append(args, stations[stationIdx].url)...)
 	var err error
 	inPipe, err = command.StdinPipe()
 	check(err)
@@ -332,124 +727,833 @@ func newStation() {
 	go func() {
 		pipeChan <- outPipe
 	}()
-	debounceWrite(saveStationAndVolumes)
+	debounceWrite.Trigger(saveStationAndVolumes)
 }
 
 func switchBacklightOn() {
 	disp.Backlight(true)
 	if *backlightOffPtr {
-		debounceBacklight(switchBacklightOff)
+		debounceBacklight.Trigger(switchBacklightOff)
 	}
 }
 
 func switchBacklightOff() {
-	disp.Backlight(false)
+	if *backlightOffPtr {
+		disp.Backlight(false)
+	}
+}
+
+// toggles the automatic switch off of the backlight
+func toggleBacklightTimeout() {
+	*backlightOffPtr = !*backlightOffPtr
+	if *backlightOffPtr {
+		printLine(layout.status, "Backlight: auto", false)
+	} else {
+		printLine(layout.status, "Backlight: on", false)
+	}
+	logger.Infof("Backlight timeout: %v", *backlightOffPtr)
+}
+
+// stops the player, saves the actual station and volumes and shuts down the system
+func shutdown() {
+	logger.Info("Shutdown requested")
+	stationMutex.Lock()
+	disp.Clear()
+	printLine(0, "Shutting down...", false)
+	stopMplayer()
+	debounceWrite.Cancel()
+	saveStationAndVolumes()
+	cmd := strings.Fields(settings.Section("system").String("shutdownCommand", "sudo shutdown -h now"))
+	if len(cmd) > 0 {
+		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
+			logger.Errorf("Shutdown failed: %s", err)
+			printLine(0, "Shutdown failed", false)
+		}
+	}
+	stationMutex.Unlock()
+}
+
+// returns the pins of the buttons. The defaults are overridden by the section 'buttons' of the config file, where
+// 'pull' and 'activeLevel' apply to all buttons and every button can be mapped to another pin or disabled with
+// 'none'. The optional push switch of the rotary encoder is the button 'encoder'.
+func loadButtonConfigs(section, encoder *config.Section) ([]input.PinConfig, []error) {
+	var errs []error
+	def, err := input.ParsePinConfig(","+section.String("pull", "up")+","+section.String("activeLevel", "low"),
+		input.PinConfig{Name: "buttons", Pull: gpio.PullUp, ActiveLow: true})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var configs []input.PinConfig
+	for _, b := range defaultButtonPins {
+		def.Name = b.name
+		value := b.pin
+		if b.name == "encoder" {
+			value = encoder.String("sw", value)
+		}
+		c, err := input.ParsePinConfig(section.String(b.name, value), def)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		configs = append(configs, c)
+	}
+	return configs, errs
+}
+
+// starts reading the optional rotary encoder that is configured in the section 'encoder' of the config file.
+// Depending on the mode, turning the encoder changes the volume or tunes the stations.
+func startEncoder(section *config.Section, pins *input.Pins, jump func(n int) func()) {
+	clk, dt := section.String("clk", ""), section.String("dt", "")
+	if clk == "" || dt == "" {
+		return
+	}
+	pinA, err := pins.Lookup("encoder.clk", clk)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	pinB, err := pins.Lookup("encoder.dt", dt)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	pull, err := input.ParsePinConfig(","+section.String("pull", "up"),
+		input.PinConfig{Name: "encoder", Pull: gpio.PullUp})
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	rotary, err := input.NewRotary(pinA, pinB, input.RotaryOptions{
+		StepsPerDetent: section.Int("stepsPerDetent", 4),
+		AccelTime:      section.Duration("accelTime", 60*time.Millisecond),
+		MaxAccel:       section.Int("maxAccel", 4),
+		Pull:           pull.Pull,
+	})
+	if err != nil {
+		check(err)
+		return
+	}
+	encoderTunesStations = section.String("mode", "volume") == "station"
+	volumeSteps := section.Int("volumeSteps", 1)
+	go func() {
+		for steps := range rotary.Turns() {
+			if encoderTunesStations {
+				jump(steps)()
+			} else {
+				action := "volumeUp"
+				if steps < 0 {
+					action = "volumeDown"
+					steps = -steps
+				}
+				for i := 0; i < steps*volumeSteps; i++ {
+					runAction(action)
+				}
+			}
+			switchBacklightOn()
+		}
+	}()
+}
+
+// switches the rotary encoder between volume and station tuning
+func toggleEncoderMode() {
+	encoderTunesStations = !encoderTunesStations
+	if encoderTunesStations {
+		printLine(layout.status, "Encoder: station", false)
+	} else {
+		printLine(layout.status, "Encoder: volume", false)
+	}
+}
+
+// returns the actions for the gestures. The defaults can be changed in the section 'gestures' of the config file
+// (e.g. 'next.long = next10'), the action 'none' disables a gesture.
+func loadGestureActions(section *config.Section) map[string]string {
+	result := map[string]string{}
+	for g, a := range gestureActions {
+		result[g] = a
+	}
+	for _, e := range section.Entries {
+		if !strings.Contains(e.Key, ".") {
+			continue
+		}
+		if e.Value == "none" {
+			delete(result, e.Key)
+		} else {
+			result[e.Key] = e.Value
+		}
+	}
+	return result
+}
+
+// adds the digit actions for the digit keys with the given prefixes (e.g. "key_kp" for "key_kp0" ... "key_kp9")
+func withDigits(keyActions map[string]string, prefixes ...string) map[string]string {
+	for _, p := range prefixes {
+		for d := 0; d <= 9; d++ {
+			keyActions[p+strconv.Itoa(d)] = "digit" + strconv.Itoa(d)
+		}
+	}
+	return keyActions
+}
+
+// returns the actions for the keys of a remote control or keyboard. The defaults can be changed in the sections
+// 'lirc.keys' and 'keyboard.keys' of the config file (e.g. 'KEY_OK = mute'), the action 'none' disables a key.
+func loadKeyActions(defaults map[string]string, section *config.Section) map[string]string {
+	result := map[string]string{}
+	for k, a := range defaults {
+		result[k] = a
+	}
+	for _, e := range section.Entries {
+		if e.Value == "none" {
+			delete(result, e.Key)
+		} else {
+			result[e.Key] = e.Value
+		}
+	}
+	return result
+}
+
+// returns the action for a key of a remote control or keyboard. A held key only repeats the actions in 'repeatableActions'
+// and only after the first 'repeatSkip' repeats, so that a short press doesn't change the volume twice.
+func keyAction(keyActions map[string]string, key input.Key, repeatSkip int) (string, bool) {
+	name, ok := keyActions[strings.ToLower(key.Button)]
+	if !ok || key.Repeat > 0 && (!repeatableActions[name] || key.Repeat <= repeatSkip) {
+		return "", false
+	}
+	return name, true
+}
+
+// starts reading the keys of remote controls from lircd, if the socket is configured in the section 'lirc' of
+// the config file
+func startLirc(section, keySection *config.Section) {
+	socket := section.String("socket", "")
+	if socket == "" {
+		return
+	}
+	remote := section.String("remote", "")
+	repeatSkip := section.Int("repeatSkip", 2)
+	keyActions := loadKeyActions(lircKeyActions, keySection)
+	lirc := input.NewLIRC(socket)
+	logger.Info("Listening for remote controls on " + socket)
+	go func() {
+		for key := range lirc.Keys() {
+			if remote != "" && key.Remote != remote {
+				continue
+			}
+			if name, ok := keyAction(keyActions, key, repeatSkip); ok {
+				runAction(name)
+				switchBacklightOn()
+			} else if key.Repeat == 0 {
+				logger.Trace("No action for key " + key.Button)
+			}
+		}
+	}()
+}
+
+// starts reading the keys of keyboards and other input devices, if enabled in the section 'keyboard' of the config
+// file. Devices that are plugged in later are detected.
+func startKeyboard(section, keySection *config.Section) {
+	if !section.Bool("enabled", false) {
+		return
+	}
+	repeatSkip := section.Int("repeatSkip", 0)
+	keyActions := loadKeyActions(keyboardKeyActions, keySection)
+	keyboard := input.NewEvdev(input.EvdevOptions{Names: section.List("devices")})
+	logger.Info("Listening for keyboards")
+	go func() {
+		for key := range keyboard.Keys() {
+			if name, ok := keyAction(keyActions, key, repeatSkip); ok {
+				runAction(name)
+				switchBacklightOn()
+			} else if key.Repeat == 0 {
+				logger.Tracef("No action for key %s of %s", key.Button, key.Remote)
+			}
+		}
+	}()
+}
+
+// starts receiving actions via HTTP, if an address is configured in the section 'http' of the config file. By
+// default only the digits, 'confirm', 'cancel' and 'pairing' are accepted.
+func startHTTP(section *config.Section) {
+	listen := section.String("listen", "")
+	if listen == "" {
+		return
+	}
+	allowed := map[string]bool{"confirm": true, "cancel": true, "pairing": true}
+	for d := 0; d <= 9; d++ {
+		allowed["digit"+strconv.Itoa(d)] = true
+	}
+	if names := section.List("actions"); len(names) > 0 {
+		allowed = map[string]bool{}
+		for _, name := range names {
+			allowed[name] = true
+		}
+	}
+	server, err := input.NewHTTP(listen, func(name string) bool {
+		_, ok := actions[name]
+		return ok && allowed[name]
+	})
+	if err != nil {
+		logger.Errorf("HTTP input not available: %s", err)
+		return
+	}
+	logger.Info("Listening for actions via HTTP on " + listen)
+	go func() {
+		for name := range server.Actions() {
+			runAction(name)
+			switchBacklightOn()
+		}
+	}()
+}
+
+// tunes the station with the given index
+func tuneStation(idx int) {
+	stationMutex.Lock()
+	stationIdx = idx
+	newStation()
+	stationMutex.Unlock()
+}
+
+// shows the name of the station that is selected by browsing, the station is tuned later
+func previewStation(idx int) {
+	if layout.station >= 0 {
+		printLine(layout.station, stations[idx].name, false)
+	} else {
+		printLine(layout.artist, stations[idx].name, false)
+	}
+}
+
+// restores the lines that were used while selecting a station
+func restoreStationLines() {
+	if layout.station >= 0 {
+		if currentStation != "" {
+			printLine(layout.station, currentStation, *scrollStationPtr)
+		} else {
+			printLine(layout.station, "-> "+stations[stationIdx].name, false)
+		}
+	} else {
+		printLine(layout.artist, "-> "+stations[stationIdx].name, false)
+	}
+	printBitrateVolume(layout.status, bitrate, volume, muted)
+}
+
+// executes the action with the given name
+func runAction(name string) {
+	if handlePairingAction(name) {
+		logger.Trace("Pairing action: " + name)
+		return
+	}
+	f, ok := actions[name]
+	if !ok {
+		logger.Warnf("Unknown action %s", name)
+		return
+	}
+	logger.Trace("Action: " + name)
+	f()
 }
 
 // reads the paired bt devices into an array and signals via 'readyForMplayer' to start the mplayer
 func checkBluetooth() {
 	// init part: get the list of paired bluetooth devices
-	result, err := exec.Command("bluetoothctl", "devices").Output()
+	manager, err := bluetooth.NewDBus()
+	if err != nil {
+		logger.Warnf("BlueZ not available via D-Bus, using bluetoothctl: %s", err)
+		manager = bluetooth.NewCtl(bluetooth.ExecRunner, 3*time.Second)
+	}
+	btManager = manager
+	btAudio = loadAudioDetector(settings.Section("bluetooth"))
+	btState = bluetooth.NewState(nil)
+	loadBtDevices()
+	if address, ok, err := bluetooth.AudioConnected(btState.Devices(), btAudio); err != nil {
+		logger.Warnf("BT audio state unknown: %s", err)
+	} else if ok {
+		logger.Info("BT connected to " + address)
+		bluetoothConnected = true
+		btAddress = address
+	}
+	readyForMplayer = true
+}
+
+// reads the paired devices in the order of their priority into the state
+func loadBtDevices() {
+	devices, err := btManager.Devices()
 	if err != nil {
 		logger.Error(err.Error())
+		return
+	}
+	logger.Info("BT Devices paired:")
+	for _, d := range bluetooth.Prioritize(devices, settings.Section("bluetooth").List("priority")) {
+		if btState.Add(d) {
+			logger.Info(d.Address + " " + d.Name)
+		}
+	}
+}
+
+// returns the switcher for the outputs in the section 'outputs' of the config file or nil, when no outputs are
+// configured and the default output of mplayer is used
+func loadOutputs(section *config.Section) *output.Switcher {
+	outputs, err := output.Load(section)
+	if err != nil {
+		logger.Errorf("%s, using the default output", err)
+		return nil
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+	checker := output.Checker{Bluetooth: btOutputAvailable}
+	return output.NewSwitcher(outputs, checker.Available)
+}
+
+// returns true if the bluetooth device is connected and its audio output is available
+func btOutputAvailable(address string) bool {
+	if btState == nil || btAudio == nil || !btState.Connected(address) {
+		return false
+	}
+	available, err := btAudio.Available(address)
+	if err != nil {
+		// a failed check doesn't switch away from a connected device
+		logger.Warnf("BT audio state of %s unknown: %s", address, err)
+		return true
+	}
+	return available
+}
+
+// switches the output with 'change' and restarts the mplayer, when the active output has changed
+func switchOutput(change func() (bool, error)) {
+	if outputSwitcher == nil {
+		printLine(layout.status, "No outputs", false)
+		return
+	}
+	changed, err := change()
+	if err != nil {
+		logger.Warn(err.Error())
+		printLine(layout.status, "Not available", false)
+		return
+	}
+	if changed {
+		restartOutput()
 	} else {
-		arr := strings.Split(string(result), "\n")
-		logger.Info("BT Devices paired:")
-		for _, s := range arr {
-			parts := strings.Split(s, " ")
-			if len(parts) > 1 {
-				info, err2 := exec.Command("bluetoothctl", "info", parts[1]).Output()
-				if err2 == nil {
-					if strings.Contains(string(info), "Audio Sink") {
-						btDevices = append(btDevices, parts[1])
-						logger.Info(parts[1])
-						if strings.Contains(string(info), "Connected: yes") {
-							logger.Info("BT connected to " + parts[1])
-							bluetoothConnected = true
-						}
-					}
-				}
-			}
+		showOutput()
+	}
+}
+
+// restarts the mplayer with the active output
+func restartOutput() {
+	stationMutex.Lock()
+	newStation()
+	stationMutex.Unlock()
+	showOutput()
+}
+
+// shows the active output in the status line
+func showOutput() {
+	if o, ok := outputSwitcher.Active(); ok {
+		logger.Info("Output: " + o.Name)
+		printLine(layout.status, "Output: "+o.Name, false)
+	} else {
+		logger.Warn("No output available")
+		printLine(layout.status, "Output: none", false)
+	}
+}
+
+// checks the outputs every 3 seconds. When the active output disappears, the player is restarted with the next
+// available output; when the selected output appears again, it's used again.
+func monitorOutputs() {
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if outputSwitcher.Check() {
+			logger.Info("Active output has changed")
+			restartOutput()
 		}
 	}
-	readyForMplayer = true
 }
 
-// listens for BT events and restarts the mplayer if event detected
+// returns the detector for the audio output of a bluetooth device. The setting 'audio' in the section
+// 'bluetooth' of the config file lists the audio backends ('pulse', 'bluealsa'); with 'auto' all are checked and
+// with 'connected' the connection of the device is sufficient.
+func loadAudioDetector(section *config.Section) bluetooth.AudioDetector {
+	backends := section.List("audio")
+	switch {
+	case len(backends) == 0 || backends[0] == "auto":
+		backends = []string{"pulse", "bluealsa"}
+	case backends[0] == "connected":
+		backends = nil
+	}
+	checker, err := bluetooth.NewAudioChecker(bluetooth.ExecRunner, backends...)
+	if err != nil {
+		logger.Errorf("%s, using all audio backends", err)
+		checker, _ = bluetooth.NewAudioChecker(bluetooth.ExecRunner, "pulse", "bluealsa")
+	}
+	return checker
+}
+
+// connects the first paired device that is available
+func connectBtDevice(manager bluetooth.Manager, devices []bluetooth.Device) bool {
+	for _, btDevice := range devices {
+		if err := manager.Connect(btDevice.Address); err == nil {
+			logger.Info("Success with device " + btDevice.Address)
+			return true
+		}
+	}
+	return false
+}
+
+// listens for BT events and restarts the mplayer if the audio output of a device appeared or disappeared.
+// The connection state is taken from the events, the audio output of the connected devices is checked on every
+// event and every 3 seconds, because it shows up a bit later than the connection. When the audio backends can't
+// be checked, the state is kept. While no device is connected, the paired devices are connected in the order of
+// their priority. The delay between the attempts increases up to 'maxBackoff'.
 func listenForBtChanges() {
-	lastExitCode := 999
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+	backoff := bluetooth.NewBackoff(3*time.Second, settings.Section("bluetooth").Duration("maxBackoff", 5*time.Minute))
+	nextAttempt := time.Now()
 	for {
-		cmd := exec.Command("ls", "/dev/input/event0")
-		_ = cmd.Run()
-		exitCode := cmd.ProcessState.ExitCode()
-		if exitCode == 2 {
-			// not connected
-			if lastExitCode == 0 {
-				logger.Info("Re-run mplayer (2)... ")
-				bluetoothConnected = false
+		tick := false
+		select {
+		case e, ok := <-btManager.Events():
+			if !ok {
+				return
+			}
+			btState.Update(e)
+			// something has changed (e.g. a speaker was switched on), so the next attempt is made immediately
+			backoff.Reset()
+			nextAttempt = time.Now()
+		case <-ticker.C:
+			tick = true
+		}
+		address, connected, err := bluetooth.AudioConnected(btState.Devices(), btAudio)
+		if err != nil {
+			logger.Warnf("BT audio state unknown: %s", err)
+			continue
+		}
+		if connected != bluetoothConnected || (connected && address != btAddress) {
+			bluetoothConnected = connected
+			btAddress = address
+			// with configured outputs, the output monitor switches to the output of the device
+			if outputSwitcher == nil {
+				logger.Infof("Re-run mplayer, BT audio connected: %v %s", connected, address)
 				stationMutex.Lock()
 				newStation()
 				stationMutex.Unlock()
 			}
-			for _, btDevice := range btDevices {
-				// logger.Info(fmt.Sprintf("Trying to connect device #%d %s", idx, btDevice))
-				cmd = exec.Command("bluetoothctl", "connect", btDevice)
-				_ = cmd.Run()
-				connectExitCode := cmd.ProcessState.ExitCode()
-				if connectExitCode == 0 {
-					logger.Info("Success with device " + btDevice)
-					break
-				}
+		}
+		if connected {
+			backoff.Reset()
+		} else if tick && !time.Now().Before(nextAttempt) {
+			if len(btState.Devices()) == 0 {
+				// the devices couldn't be read at the start
+				loadBtDevices()
 			}
-		} else if exitCode == 0 {
-			// connected
-			if lastExitCode == 2 {
-				logger.Info("Re-run mplayer (0)... ")
-				bluetoothConnected = true
-				stationMutex.Lock()
-				newStation()
-				stationMutex.Unlock()
+			if !connectBtDevice(btManager, btState.Devices()) {
+				nextAttempt = time.Now().Add(backoff.Next())
 			}
 		}
-		lastExitCode = exitCode
-		time.Sleep(3 * time.Second)
 	}
 }
 
-// removes unneeded/unwanted strings from the title like " (CDM EDIT)" etc.
-func removeNoise(title string) string {
-	opening := strings.Index(title, "(")
-	closing := strings.Index(title, ")")
-	// text must be enclosed by round brackets
-	if opening >= 0 && closing >= 0 && closing > opening {
-		remove := false
-		// fmt.Println("removing noise...")
-		noise := strings.ToLower(title[opening+1 : closing])
-		if len(noise) > 0 {
-			// fmt.Println("noise:", noise)
-			if strings.Contains(noise, "edit") ||
-				strings.Contains(noise, "mix") ||
-				strings.Contains(noise, "cdm") ||
-				strings.Contains(noise, "cut") ||
-				strings.Contains(noise, "rmx") ||
-				strings.Contains(noise, "cover") {
-				remove = true
+// returns the provisioner for the Wi-Fi setup. The settings are read from the section 'provisioning' of the
+// config file, the backend is NetworkManager (if it's running) or wpa_supplicant with hostapd.
+func loadProvisioner(section *config.Section) *provision.Provisioner {
+	iface := section.String("interface", "wlan0")
+	nm := &provision.NetworkManager{Interface: iface}
+	wpa := &provision.Wpa{
+		Interface:   iface,
+		ConfigFile:  section.String("wpaConfig", ""),
+		HostapdFile: section.String("hostapdConfig", ""),
+	}
+	var backend provision.Backend = wpa
+	provisionURL = section.String("url", "http://192.168.4.1")
+	name := section.String("backend", "auto")
+	if name == "networkmanager" || (name == "auto" && nm.Running()) {
+		backend = nm
+		provisionURL = section.String("url", "http://10.42.0.1")
+	}
+	provisionTimeout = section.Duration("timeout", 5*time.Minute)
+	provisionDuration = section.Duration("duration", 15*time.Minute)
+	return provision.New(provision.Options{
+		Backend:    backend,
+		SSID:       section.String("ssid", "piradio-setup"),
+		Passphrase: section.String("passphrase", ""),
+		Listen:     section.String("listen", ":80"),
+		Done:       provisioningDone,
+	})
+}
+
+// starts the provisioning mode, when the network is offline for the time 'timeout'
+func checkProvisioning(s network.Status) {
+	networkMutex.Lock()
+	if s.Online {
+		offlineSince = time.Time{}
+		networkMutex.Unlock()
+		return
+	}
+	if offlineSince.IsZero() {
+		offlineSince = time.Now()
+	}
+	start := provisionTimeout > 0 && time.Since(offlineSince) >= provisionTimeout && !provisioner.Active()
+	networkMutex.Unlock()
+	if start {
+		logger.Warnf("No network for %s, starting the Wi-Fi setup", provisionTimeout)
+		startProvisioning()
+	}
+}
+
+// starts or ends the provisioning mode
+func toggleProvisioning() {
+	if provisioner.Active() {
+		stopProvisioning()
+	} else {
+		go startProvisioning()
+	}
+}
+
+// scans the Wi-Fi networks and starts the access point with the setup page. The mode ends after 'duration'.
+func startProvisioning() {
+	printLine(layout.status, "Scanning...", false)
+	if err := provisioner.Start(); err != nil {
+		logger.Errorf("Wi-Fi setup failed: %s", err)
+		printLine(layout.status, "Wi-Fi setup failed", false)
+		return
+	}
+	logger.Infof("Wi-Fi setup started, access point %s, page %s", provisioner.SSID(), provisionURL)
+	passphrase := provisioner.Passphrase()
+	networkMutex.Lock()
+	if provisionTimer != nil {
+		provisionTimer.Stop()
+	}
+	provisionTimer = time.AfterFunc(provisionDuration, func() {
+		logger.Info("Wi-Fi setup timed out")
+		stopProvisioning()
+	})
+	networkMutex.Unlock()
+	// the passphrase is needed to connect to the access point, so it's always shown
+	if layout.artist == layout.title {
+		printLine(layout.artist, "Wi-Fi "+provisioner.SSID()+" Key "+passphrase+" "+provisionURL, true, true)
+	} else {
+		printLine(layout.artist, "Wi-Fi: "+provisioner.SSID(), false, true)
+		printLine(layout.title, "Key: "+passphrase, false, true)
+	}
+	printLine(layout.status, provisionURL, false, true)
+}
+
+// ends the provisioning mode without changing the network
+func stopProvisioning() {
+	if err := provisioner.Stop(); err != nil {
+		logger.Errorf("Stopping the Wi-Fi setup failed: %s", err)
+	}
+	endProvisioning()
+	if stationIdx >= 0 {
+		restoreStationLines()
+	}
+}
+
+// stops the timer of the provisioning mode; the next automatic start is after another 'timeout'
+func endProvisioning() {
+	networkMutex.Lock()
+	if provisionTimer != nil {
+		provisionTimer.Stop()
+		provisionTimer = nil
+	}
+	if !offlineSince.IsZero() {
+		offlineSince = time.Now()
+	}
+	networkMutex.Unlock()
+}
+
+// is called, when the credentials entered on the setup page were applied
+func provisioningDone(ssid string, err error) {
+	endProvisioning()
+	if err != nil {
+		logger.Errorf("Connecting to %s failed: %s", ssid, err)
+		printLine(layout.status, "Wi-Fi failed", false)
+		startProvisioning()
+		return
+	}
+	logger.Infof("Wi-Fi credentials for %s saved, connecting", ssid)
+	if stationIdx >= 0 {
+		restoreStationLines()
+	}
+	printLine(layout.status, "Connecting...", false)
+}
+
+// starts or ends the pairing mode
+func togglePairing() {
+	pairingMutex.Lock()
+	active := pairing != nil
+	pairingMutex.Unlock()
+	if active {
+		endPairing()
+	} else {
+		go startPairing(settings.Section("bluetooth").Duration("scanTime", 10*time.Second))
+	}
+}
+
+// scans for bluetooth speakers that aren't paired yet and shows them on the display. The pairing mode ends
+// after a minute without any action.
+func startPairing(scanTime time.Duration) {
+	if btManager == nil {
+		printLine(layout.status, "No bluetooth", false)
+		return
+	}
+	pairingMutex.Lock()
+	if pairing != nil {
+		pairingMutex.Unlock()
+		return
+	}
+	pairing = &pairingMode{busy: true}
+	pairingMutex.Unlock()
+	debouncePairing.Trigger(endPairing)
+	printLine(layout.station, "BT pairing", false)
+	printLine(layout.artist, "", false)
+	printLine(layout.title, "", false)
+	printLine(layout.status, "Scanning...", false)
+
+	found, err := btManager.Discover(scanTime)
+	if err != nil {
+		logger.Errorf("BT scan failed: %s", err)
+	}
+	pairingMutex.Lock()
+	if pairing == nil {
+		// ended while scanning
+		pairingMutex.Unlock()
+		return
+	}
+	pairing.busy = false
+	pairing.candidates = found
+	pairingMutex.Unlock()
+	showPairingCandidate()
+}
+
+// shows the selected speaker of the pairing mode
+func showPairingCandidate() {
+	pairingMutex.Lock()
+	if pairing == nil {
+		pairingMutex.Unlock()
+		return
+	}
+	header, name, address, hint := "BT pairing", "No speaker found", "", "Exit: cancel"
+	if len(pairing.candidates) > 0 {
+		d := pairing.candidates[pairing.idx]
+		header = fmt.Sprintf("BT pairing %d/%d", pairing.idx+1, len(pairing.candidates))
+		name, address, hint = d.Name, d.Address, "Pair: mute/confirm"
+	}
+	pairingMutex.Unlock()
+	if layout.station >= 0 {
+		printLine(layout.station, header, false)
+	}
+	printLine(layout.artist, name, false)
+	if layout.title != layout.artist {
+		printLine(layout.title, address, false)
+	}
+	printLine(layout.status, hint, false)
+}
+
+// handles the actions while the pairing mode is active: next and previous select the speaker, mute or confirm
+// pair it and cancel ends the pairing mode. Returns false, if the action isn't handled by the pairing mode.
+func handlePairingAction(name string) bool {
+	pairingMutex.Lock()
+	active := pairing != nil
+	pairingMutex.Unlock()
+	if !active {
+		return false
+	}
+	switch name {
+	case "next", "next10", "prev", "prev10":
+		pairingMutex.Lock()
+		if n := len(pairing.candidates); n > 0 && !pairing.busy {
+			step := 1
+			if strings.HasPrefix(name, "prev") {
+				step = n - 1
 			}
+			pairing.idx = (pairing.idx + step) % n
 		}
-		if remove {
-			title = strings.ReplaceAll(title[:opening]+title[closing+1:], "  ", " ")
-			title = strings.TrimSpace(strings.ReplaceAll(title, " .", ""))
-			if *debug {
-				logger.Info("removeNoise: %s", title)
-			}
+		pairingMutex.Unlock()
+		showPairingCandidate()
+	case "mute", "confirm":
+		go pairSelected()
+	case "cancel":
+		endPairing()
+		return true
+	default:
+		return false
+	}
+	debouncePairing.Trigger(endPairing)
+	return true
+}
+
+// pairs, trusts and connects the selected speaker
+func pairSelected() {
+	pairingMutex.Lock()
+	if pairing == nil || pairing.busy || len(pairing.candidates) == 0 {
+		pairingMutex.Unlock()
+		return
+	}
+	d := pairing.candidates[pairing.idx]
+	pairing.busy = true
+	pairingMutex.Unlock()
+	printLine(layout.status, "Pairing...", false)
+	if err := btManager.Pair(d.Address); err != nil {
+		logger.Errorf("Pairing with %s failed: %s", d.Address, err)
+		pairingMutex.Lock()
+		if pairing != nil {
+			pairing.busy = false
+		}
+		pairingMutex.Unlock()
+		printLine(layout.status, "Pairing failed", false)
+		return
+	}
+	logger.Info("Paired with " + d.Address + " " + d.Name)
+	// Pair also connects the device
+	d.Connected = true
+	if !btState.Add(d) {
+		// a device that was paired before
+		btState.Update(bluetooth.Event{Address: d.Address, Connected: true})
+	}
+	endPairing()
+}
+
+// ends the pairing mode and restores the display
+func endPairing() {
+	debouncePairing.Cancel()
+	pairingMutex.Lock()
+	active := pairing != nil
+	pairing = nil
+	pairingMutex.Unlock()
+	if active {
+		printLine(layout.artist, "", false)
+		printLine(layout.title, "", false)
+		restoreStationLines()
+	}
+}
+
+// removes unneeded/unwanted strings from the title like " (CDM EDIT)" etc. The rules are loaded from the file
+// '~/.piradio/rules' or the default rules are used. An empty string is returned, when the title should be dropped.
+func removeNoise(title string) string {
+	names := []string{currentStation}
+	if stationIdx >= 0 && stationIdx < len(stations) {
+		names = append(names, stationNameWithoutNumber(stations[stationIdx].name))
+	}
+	cleaned := cleanup.Apply(noiseRules, title, names...)
+	if cleaned != title && *debug {
+		logger.Info("removeNoise: %s", cleaned)
+	}
+	return cleaned
+}
+
+// removes the number that is put in front of the station names from the stations file
+func stationNameWithoutNumber(name string) string {
+	parts := strings.SplitN(name, " ", 2)
+	if len(parts) == 2 {
+		if _, err := strconv.Atoi(parts[0]); err == nil {
+			return parts[1]
 		}
 	}
-	return title
+	return name
 }
 
 func vol2VolString(vol string) string {
 	var format string
-	if charsPerLine < 20 {
+	if caps.Columns < 20 {
 		format = "V %s%%"
 	} else {
 		format = "Vol %s%%"
@@ -460,7 +1564,7 @@ func vol2VolString(vol string) string {
 func main() {
 	homePath = filepath.Join(getHomeDir(), ".piradio")
 	_ = os.MkdirAll(homePath, os.ModePerm)
-	config := logger.Config{
+	logConfig := logger.Config{
 		LogDir:          filepath.Join(homePath, "log"),
 		LogFileMaxSize:  2,
 		LogFileMaxNum:   30,
@@ -468,10 +1572,9 @@ func main() {
 		LogDest:         logger.LogDestFile,
 		Flag:            logger.ControlFlagLogDate,
 	}
-	_ = logger.Init(&config)
+	_ = logger.Init(&logConfig)
 
 	logger.Trace("Starting piradio...")
-	logNetworkInterfaces()
 
 	// Commandline parameters
 	camelCasePtr = flag.Bool("camelCase", false, "set to format title")
@@ -479,12 +1582,39 @@ func main() {
 	lcdDelayPtr = flag.Int("lcdDelay", 3, "initial delay for LCD in s (1s...10s)")
 	noisePtr = flag.Bool("noise", false, "set to remove noise from title")
 	oledPtr = flag.Bool("oled", false, "set to use OLED Display")
+	headlessPtr = flag.Bool("headless", false, "set to run without display and buttons")
 	noBluetoothPtr = flag.Bool("noBluetooth", false, "set to only use analog output")
 	backlightOffPtr = flag.Bool("backlightOff", false, "set to switch off backlight after some time")
 	backlightOffTimePtr = flag.Int("backlightOffTime", 15, "backlight switch off time in s (3s...3600s)")
 	scrollSpeedPtr = flag.Int("scrollSpeed", 500, "scroll speed in ms (100ms...10000ms)")
 	scrollStationPtr = flag.Bool("scrollStation", false, "set to scroll station names")
+	titleLayoutPtr = flag.String("titleLayout", "wrap", "set to 'wrap' to wrap long titles across lines or 'scroll' to always scroll them")
+	splitPtr = flag.String("split", "artist-title", "how the title is split into artist and title (auto, artist-title, title-artist, artist/title, title-by-artist, artist-title-album, none)")
+	charsetPtr = flag.String("charset", "windows-1252", "fallback charset for station names and titles that aren't UTF-8")
+	translitLangPtr = flag.String("translitLang", "de", "language for special characters (e.g. de, da, uk, empty for generic)")
 	flag.Parse()
+	transliterator = translit.New(*translitLangPtr)
+	settings = loadConfig(filepath.Join(homePath, "config"))
+	tc := settings.Section("titlecase")
+	caser = titlecase.New(titlecase.Options{
+		Acronyms:      tc.List("acronyms"),
+		SmallWords:    tc.List("smallWords"),
+		Exceptions:    tc.List("exceptions"),
+		RomanNumerals: tc.Bool("romanNumerals", true),
+	})
+	if mode, err := wrap.ParseMode(*titleLayoutPtr); err != nil {
+		logger.Warnf("%s, using %s", err, wrap.Wrap)
+	} else {
+		titleLayout = mode
+	}
+	if strategy, err := splitter.Parse(*splitPtr); err != nil {
+		logger.Warnf("%s, using %s", err, splitter.ArtistTitle)
+	} else {
+		splitStrategy = strategy
+	}
+	if _, err := charset.Lookup(*charsetPtr); err != nil {
+		logger.Warnf("Unknown charset %s: %s", *charsetPtr, err)
+	}
 	if *backlightOffTimePtr < 3 {
 		*backlightOffTimePtr = 3
 	}
@@ -505,69 +1635,53 @@ func main() {
 	}
 
 	var err error
-	if *oledPtr {
+	switch {
+	case *headlessPtr:
+		disp = headless.New()
+	case *oledPtr:
 		disp, err = oled.New(*scrollSpeedPtr)
-	} else {
+	default:
 		disp, err = lcd.New(*scrollStationPtr, *scrollSpeedPtr, *lcdDelayPtr)
 	}
-	charsPerLine = disp.GetCharsPerLine()
 	if err != nil {
-		logger.Error("Couldn't initialize display: %s", err)
+		// a half initialized display would block on the first output, so it's replaced
+		logger.Errorf("Couldn't initialize display, running without display: %s", err)
+		disp.Close()
+		disp = headless.New()
 	}
+	charsPerLine = disp.GetCharsPerLine()
+	caps = disp.GetCapabilities()
+	layout = newLayout(caps)
 
 	// Load gpio drivers:
-	if _, err = host.Init(); err != nil {
-		check(err)
-	}
-
-	// Lookup pins by their names and set them as input pins with an internal pull up resistor:
-	pNextStation := gpioreg.ByName("GPIO5")
-	if pNextStation == nil {
-		logger.Error("Failed to find GPIO5")
-	}
-	if err := pNextStation.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		check(err)
-	}
-
-	pPrevStation := gpioreg.ByName("GPIO6")
-	if pPrevStation == nil {
-		logger.Error("Failed to find GPIO6")
-	}
-	if err := pPrevStation.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		check(err)
-	}
-
-	pVolUp := gpioreg.ByName("GPIO19")
-	if pVolUp == nil {
-		logger.Error("Failed to find GPIO19")
-	}
-	if err := pVolUp.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		check(err)
-	}
-
-	pVolDown := gpioreg.ByName("GPIO26")
-	if pVolDown == nil {
-		logger.Error("Failed to find GPIO26")
-	}
-	if err := pVolDown.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		check(err)
+	gpioAvailable := !*headlessPtr
+	if gpioAvailable {
+		if _, err = host.Init(); err != nil {
+			logger.Errorf("Couldn't load GPIO drivers, running without buttons: %s", err)
+			gpioAvailable = false
+		}
 	}
 
-	pMuteAudio := gpioreg.ByName("GPIO16")
-	if pMuteAudio == nil {
-		logger.Error("Failed to find GPIO16")
-	}
-	if err := pMuteAudio.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		check(err)
+	// Lookup pins by their names. Every pin can only be used once, missing pins and conflicts are logged and the
+	// affected buttons are disabled:
+	pins := input.NewPins()
+	var buttons []input.Button
+	if gpioAvailable {
+		buttonConfigs, errs := loadButtonConfigs(settings.Section("buttons"), settings.Section("encoder"))
+		var pinErrs []error
+		buttons, pinErrs = pins.Buttons(buttonConfigs)
+		for _, err := range append(errs, pinErrs...) {
+			logger.Error(err.Error())
+		}
 	}
 
 	var statusChan = make(chan string)
-	var ctrlChan = make(chan os.Signal)
+	var ctrlChan = make(chan os.Signal, 1)
 	var volumeMutex = &sync.Mutex{}
 
-	debounceBtn := debouncer.New(debounceTime * time.Millisecond)
-	debounceWrite = debouncer.New(debounceWriteToFileTime * time.Second)
-	debounceBacklight = debouncer.New(time.Duration(*backlightOffTimePtr) * time.Second)
+	// the file is written at the latest after a minute, even if the station or volume is changed continuously
+	debounceWrite = debouncer.New(debounceWriteToFileTime*time.Second, debouncer.Options{MaxWait: time.Minute})
+	debounceBacklight = debouncer.New(time.Duration(*backlightOffTimePtr)*time.Second, debouncer.Options{})
 
 	// the following 4 functions handle the pressed buttons
 	fpPrev := func() {
@@ -591,14 +1705,14 @@ func main() {
 		_, err = inPipe.Write([]byte("*")) // increase volume
 		volumeMutex.Unlock()
 		check(err)
-		debounceWrite(saveStationAndVolumes)
+		debounceWrite.Trigger(saveStationAndVolumes)
 	}
 	fpDown := func() {
 		volumeMutex.Lock()
 		_, err = inPipe.Write([]byte("/")) // decrease volume
 		volumeMutex.Unlock()
 		check(err)
-		debounceWrite(saveStationAndVolumes)
+		debounceWrite.Trigger(saveStationAndVolumes)
 	}
 	fpMute := func() {
 		volumeMutex.Lock()
@@ -606,45 +1720,135 @@ func main() {
 		volumeMutex.Unlock()
 		check(err)
 	}
+	fpJump := func(n int) func() {
+		return func() {
+			stationMutex.Lock()
+			stationIdx = ((stationIdx+n)%len(stations) + len(stations)) % len(stations)
+			newStation()
+			stationMutex.Unlock()
+		}
+	}
+	// with the option 'browse', only the station names are shown and the station is tuned after a short delay
+	browse := settings.Section("selection").Bool("browse", false)
+	fpBrowse := func(n int, tune func()) func() {
+		if !browse {
+			return tune
+		}
+		return func() {
+			stationSelector.Browse(stationIdx, n)
+		}
+	}
+	actions = map[string]func(){
+		"next":   fpBrowse(1, fpNext),
+		"prev":   fpBrowse(-1, fpPrev),
+		"next10": fpBrowse(10, fpJump(10)),
+		"prev10": fpBrowse(-10, fpJump(-10)),
+		"volumeUp": func() {
+			if !muted {
+				fpUp()
+			}
+		},
+		"volumeDown": func() {
+			if !muted {
+				fpDown()
+			}
+		},
+		"mute":             fpMute,
+		"backlightTimeout": toggleBacklightTimeout,
+		"shutdown":         shutdown,
+		"encoderMode":      toggleEncoderMode,
+		"confirm":          func() { stationSelector.Confirm() },
+		"cancel":           func() { stationSelector.Cancel() },
+		"pairing":          togglePairing,
+		"output":           func() { switchOutput(func() (bool, error) { return outputSwitcher.Next(), nil }) },
+		"provisioning":     toggleProvisioning,
+	}
+	for d := 0; d <= 9; d++ {
+		digit := d
+		actions["digit"+strconv.Itoa(d)] = func() { stationSelector.Digit(digit) }
+	}
+	outputSwitcher = loadOutputs(settings.Section("outputs"))
+	if outputSwitcher != nil {
+		for _, o := range outputSwitcher.Outputs() {
+			name := o.Name
+			actions["output."+name] = func() {
+				switchOutput(func() (bool, error) { return outputSwitcher.Select(name) })
+			}
+		}
+	}
 
 	signal.Notify(ctrlChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL)
 
 	stations = loadStations(filepath.Join(homePath, "stations"))
-	stationIdx, volumeAnalog, volumeBluetooth = getStationAndVolumes()
+	rulesFile := filepath.Join(homePath, "rules")
+	if fileExists(rulesFile) {
+		noiseRules, err = cleanup.Load(rulesFile)
+		if err != nil {
+			logger.Errorf("Couldn't load rules from %s: %s", rulesFile, err)
+			noiseRules = cleanup.Default()
+		}
+	}
+	stationIdx, volumes = getStationAndVolumes()
+	selectionSection := settings.Section("selection")
+	stationSelector = selector.New(selector.Options{
+		Stations:     len(stations),
+		DigitTimeout: selectionSection.Duration("digitTimeout", 2*time.Second),
+		BrowseDelay:  selectionSection.Duration("browseDelay", 1500*time.Millisecond),
+		Show:         func(text string) { printLine(layout.status, text, false) },
+		Preview:      previewStation,
+		Tune:         tuneStation,
+		Abort:        restoreStationLines,
+	})
 	go checkBluetooth()
 
-	// this function is polling the GPIO Levels and calls the debouncer when a Low-Level is found (pull up resistor)
-	go func() {
-		for {
-			switch {
-			case pNextStation.Read() == false:
-				debounceBtn(fpNext) // next station
-				switchBacklightOn()
-			case pPrevStation.Read() == false:
-				debounceBtn(fpPrev) // previous station
-				switchBacklightOn()
-			case pVolUp.Read() == false:
-				if !muted {
-					debounceBtn(fpUp) // increase volume
-				}
-				switchBacklightOn()
-			case pVolDown.Read() == false:
-				if !muted {
-					debounceBtn(fpDown) // decrease volume
+	// this goroutine receives the debounced button events from the edge detection of the GPIO pins, recognizes
+	// the gestures and executes the assigned actions
+	gestureSection := settings.Section("gestures")
+	gestureActions = loadGestureActions(gestureSection)
+	gestureOpts := gesture.Options{
+		LongTime:      gestureSection.Duration("longTime", 800*time.Millisecond),
+		HoldTime:      gestureSection.Duration("holdTime", 5*time.Second),
+		DoubleTime:    gestureSection.Duration("doubleTime", 300*time.Millisecond),
+		DoubleButtons: map[string]bool{},
+		HoldButtons:   map[string]bool{},
+	}
+	for g := range gestureActions {
+		if strings.HasSuffix(g, "."+string(gesture.Double)) {
+			gestureOpts.DoubleButtons[strings.TrimSuffix(g, "."+string(gesture.Double))] = true
+		}
+		if strings.HasSuffix(g, "."+string(gesture.Hold)) {
+			gestureOpts.HoldButtons[strings.TrimSuffix(g, "."+string(gesture.Hold))] = true
+		}
+	}
+	if len(buttons) == 0 {
+		logger.Warn("No buttons available, running without buttons")
+	} else if gpioInput, err := input.NewGPIO(buttons, buttonStableTime*time.Millisecond); err != nil {
+		check(err)
+	} else {
+		go func() {
+			for g := range gesture.New(gestureOpts).Run(gpioInput.Events()) {
+				if name, ok := gestureActions[g.String()]; ok {
+					runAction(name)
+				} else {
+					logger.Trace("No action for gesture " + g.String())
 				}
 				switchBacklightOn()
-			case pMuteAudio.Read() == false:
-				debounceBtn(fpMute) // toggle mute
-				switchBacklightOn()
 			}
-			time.Sleep(70 * time.Millisecond)
-		}
-	}()
+		}()
+	}
+
+	if gpioAvailable {
+		startEncoder(settings.Section("encoder"), pins, func(n int) func() { return fpBrowse(n, fpJump(n)) })
+	}
+	startLirc(settings.Section("lirc"), settings.Section("lirc.keys"))
+	startKeyboard(settings.Section("keyboard"), settings.Section("keyboard.keys"))
+	startHTTP(settings.Section("http"))
 
 	// this goroutine is waiting for piradio being stopped
 	go func() {
 		<-ctrlChan
 		logger.Trace("Ctrl+C received... Exiting")
+		debounceWrite.Flush()
 		close(statusChan)
 		close(pipeChan)
 		os.Exit(1)
@@ -660,9 +1864,16 @@ func main() {
 				data, err := reader.ReadString('\n')
 				if err != nil {
 					statusChan <- "Playing stopped"
+					if isNetworkOffline() {
+						// the playback is resumed when the network is back
+						logger.Trace("Playing stopped... network is offline")
+						break
+					}
 					logger.Trace("Playing stopped... starting new mplayer in 10s")
 					time.Sleep(10 * time.Second)
-					newStation()
+					if !isNetworkOffline() {
+						newStation()
+					}
 					break
 				} else {
 					statusChan <- data
@@ -671,22 +1882,28 @@ func main() {
 		}
 	}()
 
-	// Is used for testing if the url is available on startup. This is important, when started via rc.local
-	// on boot, because the internet connection might not be available yet.
-	for !isConnected(stations[0].url) {
-		logger.Trace("URL %s is NOT available", stations[0].url)
-		time.Sleep(300 * time.Millisecond)
-	}
+	// The network might not be ready yet, especially when started via rc.local on boot. The playback starts as
+	// soon as it's ready.
+	provisioner = loadProvisioner(settings.Section("provisioning"))
+	trackAddress(settings.Section("network"))
+	startWireless(settings.Section("wifi"))
+	netStatus := startNetworkMonitor(settings.Section("network")).Status()
+	waitForNetwork(netStatus)
 	fpNext()
+	go followNetwork(netStatus)
 
 	if !*noBluetoothPtr {
 		go listenForBtChanges()
 	}
+	if outputSwitcher != nil {
+		go monitorOutputs()
+	}
 
 	// loop for processing the output of mplayer
 	for {
 		select {
 		case line := <-statusChan:
+			line = decodeOutput(line)
 			if *debug && len(strings.TrimSpace(line)) > 0 {
 				fmt.Print("Process output: " + line)
 			}
@@ -695,7 +1912,7 @@ func main() {
 				if len(name) > 1 {
 					s := strings.Trim(name[1], " \n")
 					// logger.Trace("Station: " + s)
-					printLine(0, s, *scrollStationPtr)
+					printLine(layout.station, s, *scrollStationPtr)
 					logger.Info("Station: " + s)
 					currentStation = s
 				}
@@ -706,16 +1923,17 @@ func main() {
 				for _, value := range st {
 					if strings.Index(value, "StreamTitle=") == 0 {
 						title := value[13 : len(value)-1]
-						trenner := strings.Index(title, " - ")
-						if trenner > 0 {
-							printLine(1, title[:trenner], true)
-							printLine(2, title[trenner+3:], true)
+						if *noisePtr {
+							title = removeNoise(title)
+						}
+						artist, song, ok := splitTitle(title)
+						if ok {
+							showTitle(artist, song)
 							if strings.TrimSpace(title) != "-" && title != currentStation {
 								logger.Info("Title:   " + title)
 							}
 						} else {
-							printLine(1, title, true)
-							printLine(2, "", false)
+							showTitle("", title)
 						}
 					}
 				}
@@ -725,7 +1943,7 @@ func main() {
 				if len(bitrateArr) > 1 {
 					bitrate = strings.Trim(bitrateArr[1], " \n")
 					logger.Trace("Bitrate: " + bitrate)
-					printBitrateVolume(3, bitrate, volume, muted)
+					printBitrateVolume(layout.status, bitrate, volume, muted)
 				}
 			}
 			if strings.Index(line, "Volume:") >= 0 {
@@ -734,19 +1952,16 @@ func main() {
 					v := strings.Split(strings.Trim(volumeArr[1], " \n"), " ")[0]
 					volume = vol2VolString(v)
 					logger.Trace("Volume: " + v)
-					printBitrateVolume(3, bitrate, volume, muted)
-					if bluetoothConnected {
-						volumeBluetooth = v
-					} else {
-						volumeAnalog = v
-					}
+					printBitrateVolume(layout.status, bitrate, volume, muted)
+					device, kind := currentOutput()
+					rememberVolume(device, kind, v)
 				}
 			}
 			if strings.Index(line, "Mute:") >= 0 {
 				muteArr := strings.Split(line, ":")
 				if len(muteArr) > 1 {
 					muted = strings.Contains(muteArr[1], "enabled")
-					printBitrateVolume(3, bitrate, volume, muted)
+					printBitrateVolume(layout.status, bitrate, volume, muted)
 				}
 			}
 		}
@@ -1,9 +1,31 @@
 package main
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aluedtke7/piradio/bluetooth"
+	"github.com/aluedtke7/piradio/cleanup"
+	"github.com/aluedtke7/piradio/config"
+	"github.com/aluedtke7/piradio/display"
+	"github.com/aluedtke7/piradio/input"
+	"github.com/aluedtke7/piradio/network"
+	"github.com/aluedtke7/piradio/output"
+	"github.com/aluedtke7/piradio/provision"
+	"github.com/aluedtke7/piradio/splitter"
+	"github.com/aluedtke7/piradio/wrap"
+	"github.com/antigloss/go/logger"
+	"periph.io/x/periph/conn/gpio"
 )
 
+func TestMain(m *testing.M) {
+	_ = logger.Init(&logger.Config{LogDir: os.TempDir(), LogDest: logger.LogDestNone})
+	os.Exit(m.Run())
+}
+
 func TestBeautify(t *testing.T) {
 	camelCasePtr = new(bool)
 	*camelCasePtr = false
@@ -34,10 +56,80 @@ func TestBeautify(t *testing.T) {
 		t.Error("Abc Def Ghi Jkl Mno Pqrst Uvwxyz", s)
 	}
 
-	s = beautify("abc MNO uvwâxyz")
+	s = beautify("abc MNO uvw日xyz")
 	if s != "Abc Mno Uvwxyz" {
 		t.Error("Abc Mno Uvwxyz", s)
 	}
+
+	s = beautify("ABBA - DANCING QUEEN")
+	if s != "ABBA - Dancing Queen" {
+		t.Error("ABBA - Dancing Queen", s)
+	}
+
+	s = beautify("AC/DC")
+	if s != "AC/DC" {
+		t.Error("AC/DC", s)
+	}
+
+	s = beautify("DJ ÖTZI FEAT. NIK P. - EIN STERN")
+	if s != "DJ Oetzi feat. Nik P. - Ein Stern" {
+		t.Error("DJ Oetzi feat. Nik P. - Ein Stern", s)
+	}
+
+	s = beautify("DON'T STOP ME NOW")
+	if s != "Don't Stop Me Now" {
+		t.Error("Don't Stop Me Now", s)
+	}
+
+	s = beautify("ROCKY II - EYE OF THE TIGER")
+	if s != "Rocky II - Eye of the Tiger" {
+		t.Error("Rocky II - Eye of the Tiger", s)
+	}
+
+	*camelCasePtr = false
+	s = beautify("uvwâxyz Dvořák Чайковский")
+	if s != "uvwaxyz Dvorak Chaykovskiy" {
+		t.Error("uvwaxyz Dvorak Chaykovskiy", s)
+	}
+
+	s = beautify("Jose\u0301 Gonza\u0301lez") // decomposed accents
+	if s != "Jose Gonzalez" {
+		t.Error("Jose Gonzalez", s)
+	}
+}
+
+func TestBeautifyCapabilities(t *testing.T) {
+	camelCasePtr = new(bool)
+	defer func() { caps = display.Capabilities{} }()
+
+	caps = display.Capabilities{HasGlyph: func(r rune) bool {
+		return display.IsPrintableASCII(r) || (r >= 0xa0 && r <= 0xff)
+	}}
+	s := beautify("Æblegrød")
+	if s != "Æblegrød" {
+		t.Error("Æblegrød", s)
+	}
+
+	caps = display.Capabilities{}
+	s = beautify("Æblegrød")
+	if s != "Aeblegrod" {
+		t.Error("Aeblegrod", s)
+	}
+}
+
+func TestNewLayout(t *testing.T) {
+	l := newLayout(display.Capabilities{Lines: 4})
+	if l != (screenLayout{station: 0, artist: 1, title: 2, status: 3}) {
+		t.Error("4 lines", l)
+	}
+	l = newLayout(display.Capabilities{Lines: 8})
+	if l.status != 7 {
+		t.Error("8 lines", l)
+	}
+	l = newLayout(display.Capabilities{Lines: 2})
+	if l.station != -1 || l.artist != l.title || l.status != 1 {
+		t.Error("2 lines", l)
+	}
 }
 
 func TestIsAllLowercase(t *testing.T) {
@@ -119,3 +211,480 @@ func TestRemoveCoverNoise(t *testing.T) {
 		t.Error("TestRemoveCoverNoise :", res)
 	}
 }
+
+func TestParseStationOptions(t *testing.T) {
+	station := radioStation{name: "1 Radio Sofia", url: "http://localhost"}
+	parseStationOptions(&station, []string{" charset = cp1251", "unknown=1", "garbage"})
+	if station.charset != "cp1251" {
+		t.Error("cp1251", station.charset)
+	}
+
+	station = radioStation{name: "2 Radio"}
+	parseStationOptions(&station, []string{"charset=klingon"})
+	if station.charset != "" {
+		t.Error("unknown charset must be ignored", station.charset)
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	charsetPtr = new(string)
+	*charsetPtr = "latin1"
+	stations = []radioStation{{name: "1 Latin"}, {name: "2 Cyrillic", charset: "cp1251"}}
+	defer func() { stations, stationIdx = nil, -1 }()
+
+	stationIdx = 0
+	s := decodeOutput("ICY Info: StreamTitle='Die \xc4rzte - Schrei nach Liebe';")
+	if s != "ICY Info: StreamTitle='Die Ärzte - Schrei nach Liebe';" {
+		t.Error("latin1", s)
+	}
+
+	stationIdx = 1
+	s = decodeOutput("Name   : \xd0\xe0\xe4\xe8\xee")
+	if s != "Name   : Радио" {
+		t.Error("cp1251", s)
+	}
+
+	s = decodeOutput("ICY Info: StreamTitle='Café';")
+	if s != "ICY Info: StreamTitle='Café';" {
+		t.Error("utf-8", s)
+	}
+}
+
+func TestRemoveStationNoise(t *testing.T) {
+	setDebug()
+	var err error
+	noiseRules, err = cleanup.Parse(strings.NewReader("[NDR2]\ndrop Werbung"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { noiseRules, stations, stationIdx = cleanup.Default(), nil, -1 }()
+
+	stations = []radioStation{{name: "1 NDR2"}, {name: "2 NJoy"}}
+	stationIdx = 0
+	res := removeNoise("*** Werbung ***")
+	if res != "" {
+		t.Error("TestRemoveStationNoise :", res)
+	}
+	stationIdx = 1
+	res = removeNoise("*** Werbung ***")
+	if res != "*** Werbung ***" {
+		t.Error("TestRemoveStationNoise :", res)
+	}
+}
+
+func TestStationNameWithoutNumber(t *testing.T) {
+	for name, want := range map[string]string{"12 NDR2": "NDR2", "RadioHH": "RadioHH", "1 Jazz Radio": "Jazz Radio"} {
+		if s := stationNameWithoutNumber(name); s != want {
+			t.Error(want, s)
+		}
+	}
+}
+
+func TestSplitTitle(t *testing.T) {
+	stations = []radioStation{{name: "1 Default"}, {name: "2 Reverse", split: splitter.TitleArtist}}
+	defer func() { stations, stationIdx = nil, -1 }()
+
+	stationIdx = 0
+	artist, title, ok := splitTitle("Sting - Fragile")
+	if !ok || artist != "Sting" || title != "Fragile" {
+		t.Error("artist-title", artist, title, ok)
+	}
+	stationIdx = 1
+	artist, title, ok = splitTitle("Fragile - Sting")
+	if !ok || artist != "Sting" || title != "Fragile" {
+		t.Error("title-artist", artist, title, ok)
+	}
+
+	station := radioStation{name: "3 Auto"}
+	parseStationOptions(&station, []string{"split=auto"})
+	if station.split != splitter.Auto {
+		t.Error("auto", station.split)
+	}
+}
+
+// fakeDisplay records the printed lines
+type fakeDisplay struct {
+	charsPerLine int
+	lines        []string
+	scroll       []bool
+}
+
+func newFakeDisplay(lines int, charsPerLine int) *fakeDisplay {
+	return &fakeDisplay{charsPerLine: charsPerLine, lines: make([]string, lines), scroll: make([]bool, lines)}
+}
+
+func (f *fakeDisplay) Backlight(_ bool) {}
+func (f *fakeDisplay) Clear()           {}
+func (f *fakeDisplay) ClearLine(_ int)  {}
+func (f *fakeDisplay) Close()           {}
+func (f *fakeDisplay) GetCapabilities() display.Capabilities {
+	return display.Capabilities{Lines: len(f.lines), Columns: f.charsPerLine}
+}
+func (f *fakeDisplay) GetCharsPerLine() int { return f.charsPerLine }
+func (f *fakeDisplay) PrintLine(line int, text string, scroll bool) {
+	f.lines[line] = text
+	f.scroll[line] = scroll
+}
+
+// replaces the display for a test and returns a function that restores the previous state
+func useFakeDisplay(f *fakeDisplay) func() {
+	oldDisp, oldCaps, oldLayout, oldChars := disp, caps, layout, charsPerLine
+	disp = f
+	caps = f.GetCapabilities()
+	layout = newLayout(caps)
+	charsPerLine = f.GetCharsPerLine()
+	return func() {
+		disp, caps, layout, charsPerLine = oldDisp, oldCaps, oldLayout, oldChars
+	}
+}
+
+func TestShowTitle(t *testing.T) {
+	camelCasePtr = new(bool)
+	tests := []struct {
+		name   string
+		width  int
+		mode   wrap.Mode
+		artist string
+		title  string
+		lines  []string
+		scroll []bool
+	}{
+		{"LCD wrap", 20, wrap.Wrap, "Sting", "Englishman in New York",
+			[]string{"Sting - Englishman", "in New York"}, []bool{false, false}},
+		{"OLED wrap", 18, wrap.Wrap, "Sting", "Englishman in New York",
+			[]string{"Sting - Englishman", "in New York"}, []bool{false, false}},
+		{"OLED narrow", 18, wrap.Wrap, "", "Sting - Englishman in New",
+			[]string{"Sting - Englishman", "in New"}, []bool{false, false}},
+		{"LCD scroll", 20, wrap.Scroll, "Sting", "Englishman in New York",
+			[]string{"Sting", "Englishman in New York"}, []bool{false, true}},
+		{"Beautified", 20, wrap.Wrap, "Björk", "Jóga",
+			[]string{"Bjoerk", "Joga"}, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeDisplay(4, tt.width)
+			defer useFakeDisplay(f)()
+			titleLayout = tt.mode
+			defer func() { titleLayout = wrap.Wrap }()
+
+			showTitle(tt.artist, tt.title)
+			if f.lines[1] != tt.lines[0] || f.lines[2] != tt.lines[1] {
+				t.Errorf("lines %q", f.lines[1:3])
+			}
+			if f.scroll[1] != tt.scroll[0] || f.scroll[2] != tt.scroll[1] {
+				t.Errorf("scroll %v", f.scroll[1:3])
+			}
+		})
+	}
+}
+
+func TestLoadGestureActions(t *testing.T) {
+	c, err := config.Parse(strings.NewReader("[gestures]\nlongTime = 1s\nnext.long = next\nmute.hold = none\ndown+up.combo = mute"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ga := loadGestureActions(c.Section("gestures"))
+	if ga["next.long"] != "next" || ga["down+up.combo"] != "mute" || ga["next.short"] != "next" {
+		t.Error("gestures", ga)
+	}
+	if _, ok := ga["mute.hold"]; ok {
+		t.Error("mute.hold must be disabled")
+	}
+	if _, ok := ga["longtime"]; ok {
+		t.Error("longTime is not a gesture")
+	}
+}
+
+func TestLoadButtonConfigs(t *testing.T) {
+	c, err := config.Parse(strings.NewReader("[buttons]\npull = down\nactiveLevel = high\nup = GPIO20\nmute = none\n" +
+		"next = GPIO5, up, low\n[encoder]\nsw = GPIO22\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, errs := loadButtonConfigs(c.Section("buttons"), c.Section("encoder"))
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	want := map[string]input.PinConfig{
+		"next":    {Name: "next", Pin: "GPIO5", Pull: gpio.PullUp, ActiveLow: true},
+		"prev":    {Name: "prev", Pin: "GPIO6", Pull: gpio.PullDown},
+		"up":      {Name: "up", Pin: "GPIO20", Pull: gpio.PullDown},
+		"down":    {Name: "down", Pin: "GPIO26", Pull: gpio.PullDown},
+		"mute":    {Name: "mute", Pull: gpio.PullDown},
+		"encoder": {Name: "encoder", Pin: "GPIO22", Pull: gpio.PullDown},
+	}
+	if len(configs) != len(want) {
+		t.Fatalf("got %d buttons, want %d", len(configs), len(want))
+	}
+	for _, got := range configs {
+		if got != want[got.Name] {
+			t.Errorf("got %+v, want %+v", got, want[got.Name])
+		}
+	}
+	configs, _ = loadButtonConfigs(config.New().Section("buttons"), config.New().Section("encoder"))
+	if configs[0].Pin != "GPIO5" || !configs[0].ActiveLow || configs[0].Pull != gpio.PullUp || configs[5].Pin != "" {
+		t.Error("unexpected defaults", configs)
+	}
+	c, _ = config.Parse(strings.NewReader("[buttons]\npull = sideways\nnext = GPIO5, left\n"))
+	if _, errs = loadButtonConfigs(c.Section("buttons"), c.Section("encoder")); len(errs) != 2 {
+		t.Error("expected 2 errors", errs)
+	}
+}
+
+func TestKeyAction(t *testing.T) {
+	c, err := config.Parse(strings.NewReader("[lirc.keys]\nKEY_OK = mute\nKEY_MUTE = none\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyActions := loadKeyActions(lircKeyActions, c.Section("lirc.keys"))
+	tests := []struct {
+		button string
+		repeat int
+		want   string
+	}{
+		{"KEY_VOLUMEUP", 0, "volumeUp"},
+		{"KEY_VOLUMEUP", 2, ""},
+		{"KEY_VOLUMEUP", 3, "volumeUp"},
+		{"KEY_NEXT", 0, "next"},
+		{"KEY_NEXT", 5, ""},
+		{"KEY_7", 0, "digit7"},
+		{"KEY_OK", 0, "mute"},
+		{"KEY_MUTE", 0, ""},
+		{"KEY_UNKNOWN", 0, ""},
+	}
+	for _, tt := range tests {
+		got, ok := keyAction(keyActions, input.Key{Button: tt.button, Repeat: tt.repeat}, 2)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("keyAction(%s, %d) = %q, %v, want %q", tt.button, tt.repeat, got, ok, tt.want)
+		}
+	}
+}
+
+func TestKeyboardKeyActions(t *testing.T) {
+	keyActions := loadKeyActions(keyboardKeyActions, config.New().Section("keyboard.keys"))
+	for button, want := range map[string]string{"KEY_KP5": "digit5", "KEY_NUMERIC_0": "digit0", "KEY_3": "digit3",
+		"KEY_NEXTSONG": "next", "KEY_KPPLUS": "volumeUp"} {
+		if got, _ := keyAction(keyActions, input.Key{Button: button}, 0); got != want {
+			t.Errorf("keyAction(%s) = %q, want %q", button, got, want)
+		}
+	}
+}
+
+func TestConnectBtDevice(t *testing.T) {
+	manager := bluetooth.NewFake(bluetooth.Device{Address: "A"}, bluetooth.Device{Address: "B"},
+		bluetooth.Device{Address: "C"})
+	manager.FailConnect("A")
+	if !connectBtDevice(manager, []bluetooth.Device{{Address: "A"}, {Address: "B"}, {Address: "C"}}) {
+		t.Error("connect failed")
+	}
+	if c := manager.Connects(); len(c) != 2 || c[1] != "B" {
+		t.Errorf("unexpected connects %v", c)
+	}
+	if connectBtDevice(manager, []bluetooth.Device{{Address: "A"}}) {
+		t.Error("connect must fail")
+	}
+}
+
+func TestPairing(t *testing.T) {
+	f := newFakeDisplay(4, 20)
+	defer useFakeDisplay(f)()
+	manager := bluetooth.NewFake(bluetooth.Device{Address: "A", Name: "Kitchen"})
+	manager.AddCandidate(bluetooth.Device{Address: "B", Name: "Boombox"})
+	manager.AddCandidate(bluetooth.Device{Address: "C", Name: "Garden"})
+	manager.FailConnect("C")
+	oldManager, oldState, oldStation := btManager, btState, currentStation
+	defer func() { btManager, btState, currentStation = oldManager, oldState, oldStation }()
+	btManager, currentStation = manager, "Test FM"
+	// B was paired before, e.g. the pairing was removed on the speaker
+	btState = bluetooth.NewState([]bluetooth.Device{{Address: "A", Name: "Kitchen"}, {Address: "B"}})
+	scrollStationPtr = new(bool)
+
+	startPairing(0)
+	if f.lines[0] != "BT pairing 1/2" || f.lines[1] != "Boombox" || f.lines[2] != "B" {
+		t.Errorf("unexpected display %q", f.lines)
+	}
+	if !handlePairingAction("prev") || f.lines[1] != "Garden" {
+		t.Errorf("unexpected display %q", f.lines)
+	}
+	if handlePairingAction("volumeUp") {
+		t.Error("volumeUp must not be handled by the pairing mode")
+	}
+	pairSelected()
+	if f.lines[3] != "Pairing failed" {
+		t.Errorf("unexpected display %q", f.lines)
+	}
+	handlePairingAction("next")
+	pairSelected()
+	if f.lines[0] != "Test FM" || f.lines[1] != "" {
+		t.Errorf("unexpected display %q", f.lines)
+	}
+	if devices := btState.Devices(); len(devices) != 2 || devices[1].Address != "B" {
+		t.Errorf("unexpected devices %v", devices)
+	}
+	if !btState.Connected("B") {
+		t.Error("the paired device should be connected")
+	}
+	if handlePairingAction("next") {
+		t.Error("the pairing mode must be ended")
+	}
+}
+
+func TestVolumeFor(t *testing.T) {
+	volumes = map[string]string{}
+	if v := volumeFor("analog", "analog"); v != defVolumeAnalog {
+		t.Errorf("got %s, want default %s", v, defVolumeAnalog)
+	}
+	if v := volumeFor("AA:BB:CC:DD:EE:FF", "bluetooth"); v != defVolumeBluetooth {
+		t.Errorf("got %s, want default %s", v, defVolumeBluetooth)
+	}
+	rememberVolume("AA:BB:CC:DD:EE:FF", "bluetooth", "20")
+	rememberVolume("analog", "analog", "70")
+	if v := volumeFor("AA:BB:CC:DD:EE:FF", "bluetooth"); v != "20" {
+		t.Errorf("got %s, want 20", v)
+	}
+	// a new speaker starts with the volume of the last one
+	if v := volumeFor("11:22:33:44:55:66", "bluetooth"); v != "20" {
+		t.Errorf("got %s, want 20", v)
+	}
+	rememberVolume("11:22:33:44:55:66", "bluetooth", "40")
+	if v := volumeFor("AA:BB:CC:DD:EE:FF", "bluetooth"); v != "20" {
+		t.Errorf("got %s, want 20", v)
+	}
+	if v := volumeFor("analog", "analog"); v != "70" {
+		t.Errorf("got %s, want 70", v)
+	}
+}
+
+func TestVolumeOfOutputSaved(t *testing.T) {
+	oldHome, oldSwitcher := homePath, outputSwitcher
+	defer func() { homePath, outputSwitcher = oldHome, oldSwitcher }()
+	homePath = t.TempDir()
+	outputSwitcher = output.NewSwitcher([]output.Output{{Name: "speaker", Driver: "alsa:device=hw=0.0"}},
+		func(output.Output) bool { return true })
+	volumes = map[string]string{}
+	device, kind := currentOutput()
+	rememberVolume(device, kind, "42")
+	saveStationAndVolumes()
+
+	_, volumes = getStationAndVolumes()
+	if v := volumeFor(device, kind); v != "42" {
+		t.Errorf("got %s, want 42 (volumes %v)", v, volumes)
+	}
+}
+
+// wifiBackend is a provisioning backend that does nothing
+type wifiBackend struct{}
+
+func (wifiBackend) Scan() ([]provision.Network, error)    { return nil, nil }
+func (wifiBackend) StartAP(ssid, passphrase string) error { return nil }
+func (wifiBackend) StopAP() error                         { return nil }
+func (wifiBackend) Connect(ssid, psk string) error        { return nil }
+
+func TestCheckProvisioning(t *testing.T) {
+	f := newFakeDisplay(4, 20)
+	defer useFakeDisplay(f)()
+	oldIdx := stationIdx
+	defer func() { stationIdx = oldIdx }()
+	stationIdx = -1
+	provisioner = provision.New(provision.Options{Backend: wifiBackend{}, Listen: "127.0.0.1:0"})
+	provisionURL, provisionTimeout, provisionDuration = "http://10.42.0.1", time.Minute, time.Hour
+	offline := network.Status{Failed: network.Link, Attempt: 1}
+
+	checkProvisioning(network.Status{Online: true})
+	checkProvisioning(offline)
+	if provisioner.Active() {
+		t.Fatal("started before the timeout")
+	}
+	networkMutex.Lock()
+	offlineSince = time.Now().Add(-time.Minute)
+	networkMutex.Unlock()
+	checkProvisioning(offline)
+	if !provisioner.Active() {
+		t.Fatal("not started after the timeout")
+	}
+	passphrase := provisioner.Passphrase()
+	if passphrase == "" || f.lines[1] != "Wi-Fi: piradio-setup" || f.lines[2] != "Key: "+passphrase ||
+		f.lines[3] != "http://10.42.0.1" {
+		t.Errorf("unexpected display %q", f.lines)
+	}
+	toggleProvisioning()
+	if provisioner.Active() {
+		t.Fatal("not stopped")
+	}
+	// the next start is after another timeout
+	checkProvisioning(offline)
+	if provisioner.Active() {
+		t.Fatal("started again without a timeout")
+	}
+	checkProvisioning(network.Status{Online: true})
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	if !offlineSince.IsZero() {
+		t.Error("the offline time isn't reset")
+	}
+}
+
+func TestSignalText(t *testing.T) {
+	oldCaps, oldQuality := caps, wifiQuality
+	defer func() { caps, wifiQuality = oldCaps, oldQuality }()
+	tests := []struct {
+		hasGlyph func(r rune) bool
+		quality  network.Quality
+		want     string
+	}{
+		{nil, network.Quality{}, ""},
+		{nil, network.Quality{Interface: "wlan0", Connected: true, Bars: 3}, "W3"},
+		{nil, network.Quality{Interface: "wlan0", Connected: true, Bars: 1, Reconnects: 2}, "W1!2"},
+		{nil, network.Quality{Interface: "wlan0"}, "W-"},
+		{func(r rune) bool { return true }, network.Quality{Interface: "wlan0", Connected: true, Bars: 3}, "▆"},
+		{func(r rune) bool { return true }, network.Quality{Interface: "wlan0", Connected: true, Bars: 4,
+			Reconnects: 1}, "█!1"},
+		{func(r rune) bool { return true }, network.Quality{Interface: "wlan0", Reconnects: 1}, "-!1"},
+	}
+	for _, tt := range tests {
+		caps = display.Capabilities{Lines: 4, Columns: 20, HasGlyph: tt.hasGlyph}
+		wifiQuality = tt.quality
+		if got := signalText(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.quality, got, tt.want)
+		}
+	}
+
+	f := newFakeDisplay(4, 20)
+	defer useFakeDisplay(f)()
+	caps = display.Capabilities{Lines: 4, Columns: 20, HasGlyph: func(r rune) bool { return true }}
+	wifiQuality = network.Quality{Interface: "wlan0", Connected: true, Bars: 2}
+	printBitrateVolume(3, "128 kbit", "Vol 55%", false)
+	if f.lines[3] != "128 kbit  ▄  Vol 55%" {
+		t.Errorf("unexpected line %q", f.lines[3])
+	}
+}
+
+func TestBitrateVolumeText(t *testing.T) {
+	oldCaps, oldQuality := caps, wifiQuality
+	defer func() { caps, wifiQuality = oldCaps, oldQuality }()
+	tests := []struct {
+		columns int
+		quality network.Quality
+		muted   bool
+		want    string
+	}{
+		{20, network.Quality{}, false, "128 kbit     Vol 55%"},
+		{20, network.Quality{Interface: "wlan0", Connected: true, Bars: 3}, false, "128 kbit  W3 Vol 55%"},
+		{20, network.Quality{Interface: "wlan0", Connected: true, Bars: 3, Reconnects: 2}, false, "128 kbit  W3 Vol 55%"},
+		{20, network.Quality{Interface: "wlan0", Connected: true, Bars: 3, Reconnects: 2}, true, "128 kbit W3!2 -mute-"},
+		{16, network.Quality{Interface: "wlan0", Connected: true, Bars: 3, Reconnects: 2}, false, "128     W3 V 55%"},
+		{16, network.Quality{Interface: "wlan0", Connected: true, Bars: 3}, true, "128    W3 -mute-"},
+		{8, network.Quality{Interface: "wlan0", Connected: true, Bars: 3, Reconnects: 12}, false, "W3 V 55%"},
+	}
+	for _, tt := range tests {
+		caps = display.Capabilities{Lines: 4, Columns: tt.columns}
+		wifiQuality = tt.quality
+		got := bitrateVolumeText("128 kbit", vol2VolString("55"), tt.muted)
+		if n := utf8.RuneCountInString(got); n != tt.columns {
+			t.Errorf("%d columns, %v: %q has %d characters", tt.columns, tt.quality, got, n)
+		}
+		if got != tt.want {
+			t.Errorf("%d columns, %v: got %q, want %q", tt.columns, tt.quality, got, tt.want)
+		}
+	}
+}
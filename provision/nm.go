@@ -0,0 +1,105 @@
+package provision
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/aluedtke7/piradio/bluetooth"
+)
+
+// NetworkManager is the backend for NetworkManager. The access point is a hotspot connection, NetworkManager
+// provides the address and DHCP for it.
+type NetworkManager struct {
+	Run       bluetooth.Runner // defaults to bluetooth.ExecRunner
+	Interface string           // defaults to "wlan0"
+}
+
+// the name of the hotspot connection
+const hotspotName = "piradio-setup"
+
+func (n *NetworkManager) defaults() {
+	if n.Run == nil {
+		n.Run = bluetooth.ExecRunner
+	}
+	if n.Interface == "" {
+		n.Interface = "wlan0"
+	}
+}
+
+// Running returns true if NetworkManager is running
+func (n *NetworkManager) Running() bool {
+	n.defaults()
+	out, err := n.Run("nmcli", "-t", "-f", "RUNNING", "general")
+	return err == nil && strings.TrimSpace(string(out)) == "running"
+}
+
+func (n *NetworkManager) Scan() ([]Network, error) {
+	n.defaults()
+	out, err := n.Run("nmcli", "-t", "-f", "SSID,SIGNAL,SECURITY", "device", "wifi", "list", "ifname", n.Interface,
+		"--rescan", "yes")
+	if err != nil {
+		return nil, err
+	}
+	return ParseNmcliList(string(out)), nil
+}
+
+func (n *NetworkManager) StartAP(ssid, passphrase string) error {
+	n.defaults()
+	if passphrase == "" {
+		return errors.New("the access point needs a passphrase")
+	}
+	_, err := n.Run("nmcli", "device", "wifi", "hotspot", "ifname", n.Interface, "con-name", hotspotName,
+		"ssid", ssid, "password", passphrase)
+	return err
+}
+
+func (n *NetworkManager) StopAP() error {
+	n.defaults()
+	_, err := n.Run("nmcli", "connection", "delete", hotspotName)
+	return err
+}
+
+func (n *NetworkManager) Connect(ssid, psk string) error {
+	n.defaults()
+	args := []string{"device", "wifi", "connect", ssid, "ifname", n.Interface}
+	if psk != "" {
+		args = append(args, "password", psk)
+	}
+	_, err := n.Run("nmcli", args...)
+	return err
+}
+
+// ParseNmcliList parses the terse output of 'nmcli -t -f SSID,SIGNAL,SECURITY device wifi list'
+func ParseNmcliList(out string) []Network {
+	var networks []Network
+	for _, line := range strings.Split(out, "\n") {
+		fields := splitTerse(line)
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		signal, _ := strconv.Atoi(fields[1])
+		networks = append(networks, Network{SSID: fields[0], Signal: signal,
+			Secure: fields[2] != "" && fields[2] != "--"})
+	}
+	return networks
+}
+
+// splits a line of the terse output of nmcli at the colons, escaped colons ("\:") are kept
+func splitTerse(line string) []string {
+	var fields []string
+	var current strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(line[i])
+		}
+	}
+	return append(fields, current.String())
+}
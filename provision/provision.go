@@ -0,0 +1,311 @@
+package provision
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Network is a Wi-Fi network found by a scan
+type Network struct {
+	SSID   string
+	Signal int // signal strength in percent
+	Secure bool
+}
+
+// Backend controls the Wi-Fi interface
+type Backend interface {
+	// Scan returns the networks in range; it's called before the access point is started
+	Scan() ([]Network, error)
+	// StartAP starts the access point, secured with WPA2 and the passphrase
+	StartAP(ssid, passphrase string) error
+	// StopAP stops the access point
+	StopAP() error
+	// Connect stores the credentials and restarts the network with them; an empty psk is an open network
+	Connect(ssid, psk string) error
+}
+
+// Options configure the Provisioner
+type Options struct {
+	Backend    Backend
+	SSID       string                       // name of the access point; defaults to "piradio-setup"
+	Passphrase string                       // passphrase of the access point; generated on every start when empty
+	Listen     string                       // address of the setup page; defaults to ":80"
+	Done       func(ssid string, err error) // called after the credentials were applied (or failed)
+}
+
+// ErrActive is returned by Start, when the provisioning mode is already active
+var ErrActive = errors.New("provisioning is already active")
+
+// Provisioner runs the provisioning mode: it scans the networks, starts an access point and serves a page where
+// the network and its passphrase are entered. It's safe for concurrent use.
+type Provisioner struct {
+	opts       Options
+	mutex      sync.Mutex
+	active     bool
+	passphrase string
+	networks   []Network
+	server     *http.Server
+}
+
+/**
+  Returns a provisioner, the provisioning mode is started with Start
+*/
+func New(opts Options) *Provisioner {
+	if opts.SSID == "" {
+		opts.SSID = "piradio-setup"
+	}
+	if opts.Listen == "" {
+		opts.Listen = ":80"
+	}
+	if opts.Done == nil {
+		opts.Done = func(string, error) {}
+	}
+	return &Provisioner{opts: opts}
+}
+
+// SSID returns the name of the access point
+func (p *Provisioner) SSID() string {
+	return p.opts.SSID
+}
+
+// Passphrase returns the passphrase of the access point while the provisioning mode is active
+func (p *Provisioner) Passphrase() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.passphrase
+}
+
+// Active returns true while the provisioning mode is active
+func (p *Provisioner) Active() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.active
+}
+
+// Networks returns the networks found when the provisioning mode was started
+func (p *Provisioner) Networks() []Network {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]Network(nil), p.networks...)
+}
+
+// Start scans the networks, starts the access point and the setup page. The access point is always secured with
+// a passphrase.
+func (p *Provisioner) Start() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.active {
+		return ErrActive
+	}
+	passphrase := p.opts.Passphrase
+	if passphrase == "" {
+		passphrase = generatePassphrase()
+	} else if len(passphrase) < 8 || len(passphrase) > 63 {
+		return errors.New("the passphrase of the access point must have 8 to 63 characters")
+	}
+	networks, err := p.opts.Backend.Scan()
+	if err != nil {
+		// the networks can still be entered by hand
+		networks = nil
+	}
+	p.networks = sortNetworks(networks)
+	if err = p.opts.Backend.StartAP(p.opts.SSID, passphrase); err != nil {
+		return fmt.Errorf("starting the access point failed: %w", err)
+	}
+	listener, err := net.Listen("tcp", p.opts.Listen)
+	if err != nil {
+		_ = p.opts.Backend.StopAP()
+		return err
+	}
+	p.server = &http.Server{Handler: p.Handler()}
+	go func(server *http.Server) {
+		_ = server.Serve(listener)
+	}(p.server)
+	p.active = true
+	p.passphrase = passphrase
+	return nil
+}
+
+// the characters of a generated passphrase, without the ones that are easily confused (like 0, o, 1 and l)
+const passphraseChars = "abcdefghijkmnpqrstuvwxyz23456789"
+
+// returns a random passphrase with 8 characters
+func generatePassphrase() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = passphraseChars[int(b[i])%len(passphraseChars)]
+	}
+	return string(b)
+}
+
+// Stop stops the setup page and the access point
+func (p *Provisioner) Stop() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.stop()
+}
+
+// must be called with the lock held
+func (p *Provisioner) stop() error {
+	if !p.active {
+		return nil
+	}
+	p.active = false
+	p.passphrase = ""
+	// the answer to the form is sent before the access point goes down
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = p.server.Shutdown(ctx)
+	p.server = nil
+	return p.opts.Backend.StopAP()
+}
+
+// Apply stops the provisioning mode and connects to the network. The result is passed to the Done function.
+func (p *Provisioner) Apply(ssid, psk string) error {
+	if err := Validate(ssid, psk); err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	if !p.active {
+		p.mutex.Unlock()
+		return errors.New("provisioning isn't active")
+	}
+	err := p.stop()
+	p.mutex.Unlock()
+	if err == nil {
+		err = p.opts.Backend.Connect(ssid, psk)
+	}
+	p.opts.Done(ssid, err)
+	return err
+}
+
+/**
+  Checks the SSID (1 to 32 bytes) and the pre-shared key: empty for an open network, a passphrase with 8 to 63
+  printable ASCII characters or 64 hex digits
+*/
+func Validate(ssid, psk string) error {
+	if len(ssid) == 0 || len(ssid) > 32 {
+		return errors.New("the network name must have 1 to 32 characters")
+	}
+	if strings.ContainsAny(ssid, "\n\r\x00") {
+		return errors.New("the network name contains invalid characters")
+	}
+	if psk == "" || isHexKey(psk) {
+		return nil
+	}
+	if len(psk) < 8 || len(psk) > 63 {
+		return errors.New("the passphrase must have 8 to 63 characters")
+	}
+	for _, c := range psk {
+		if c < 32 || c > 126 {
+			return errors.New("the passphrase contains invalid characters")
+		}
+	}
+	return nil
+}
+
+// returns true if the key is a raw key of 64 hex digits
+func isHexKey(psk string) bool {
+	if len(psk) != 64 {
+		return false
+	}
+	return strings.Trim(strings.ToLower(psk), "0123456789abcdef") == ""
+}
+
+// returns the networks with the strongest signal per SSID, sorted by the signal
+func sortNetworks(networks []Network) []Network {
+	best := map[string]Network{}
+	for _, n := range networks {
+		if n.SSID == "" {
+			continue
+		}
+		if b, ok := best[n.SSID]; !ok || n.Signal > b.Signal {
+			best[n.SSID] = n
+		}
+	}
+	result := make([]Network, 0, len(best))
+	for _, n := range best {
+		result = append(result, n)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Signal != result[j].Signal {
+			return result[i].Signal > result[j].Signal
+		}
+		return result[i].SSID < result[j].SSID
+	})
+	return result
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>piradio Wi-Fi setup</title>
+</head>
+<body>
+<h1>piradio Wi-Fi setup</h1>
+{{if .Message}}<p><b>{{.Message}}</b></p>{{end}}
+{{if .Done}}<p>The radio connects to {{.SSID}} now. This access point will disappear.</p>{{else}}
+<form method="post" action="/">
+<p><label for="ssid">Network</label><br>
+<select id="ssid" name="ssid">
+{{range .Networks}}<option value="{{.SSID}}"{{if eq .SSID $.SSID}} selected{{end}}>{{.SSID}} ({{.Signal}}%{{if .Secure}}, secured{{end}})</option>
+{{end}}<option value="">other network:</option>
+</select>
+<input name="other" placeholder="network name"></p>
+<p><label for="psk">Passphrase</label><br>
+<input id="psk" name="psk" type="password"></p>
+<p><input type="submit" value="Connect"></p>
+</form>{{end}}
+</body>
+</html>
+`))
+
+type pageData struct {
+	Networks []Network
+	SSID     string
+	Message  string
+	Done     bool
+}
+
+// Handler returns the handler of the setup page. The form is posted to '/', the credentials are applied after
+// the answer was sent.
+func (p *Provisioner) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := pageData{Networks: p.Networks()}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.Method == http.MethodPost {
+			data.SSID = r.FormValue("ssid")
+			if data.SSID == "" {
+				data.SSID = strings.TrimSpace(r.FormValue("other"))
+			}
+			psk := r.FormValue("psk")
+			if err := Validate(data.SSID, psk); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				data.Message = err.Error()
+			} else {
+				data.Done = true
+				go func() {
+					_ = p.Apply(data.SSID, psk)
+				}()
+			}
+		} else if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		_ = page.Execute(w, data)
+	})
+}
@@ -0,0 +1,200 @@
+package provision
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend records the calls
+type fakeBackend struct {
+	mutex      sync.Mutex
+	networks   []Network
+	scanErr    error
+	connectErr error
+	calls      []string
+}
+
+func (f *fakeBackend) record(call string) {
+	f.mutex.Lock()
+	f.calls = append(f.calls, call)
+	f.mutex.Unlock()
+}
+
+func (f *fakeBackend) Scan() ([]Network, error) {
+	f.record("scan")
+	return f.networks, f.scanErr
+}
+
+func (f *fakeBackend) StartAP(ssid, passphrase string) error {
+	f.record("start " + ssid + " " + passphrase)
+	return nil
+}
+
+func (f *fakeBackend) StopAP() error {
+	f.record("stop")
+	return nil
+}
+
+func (f *fakeBackend) Connect(ssid, psk string) error {
+	f.record("connect " + ssid + " " + psk)
+	return f.connectErr
+}
+
+func (f *fakeBackend) expect(t *testing.T, want ...string) {
+	t.Helper()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if strings.Join(f.calls, "|") != strings.Join(want, "|") {
+		t.Fatalf("got calls %q, want %q", f.calls, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		ssid, psk string
+		ok        bool
+	}{
+		{"Home", "secret123", true},
+		{"Home", "", true},
+		{"Home", strings.Repeat("ab", 32), true},
+		{"Home", "short", false},
+		{"Home", strings.Repeat("x", 64), false},
+		{"Home", "pass\nword", false},
+		{"", "secret123", false},
+		{strings.Repeat("x", 33), "secret123", false},
+		{"Ho\nme", "secret123", false},
+		{"Café \"2\"", "secret123", true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.ssid, tt.psk); (err == nil) != tt.ok {
+			t.Errorf("%q %q: got %v", tt.ssid, tt.psk, err)
+		}
+	}
+}
+
+func TestSortNetworks(t *testing.T) {
+	got := sortNetworks([]Network{{"A", 40, true}, {"B", 70, false}, {"A", 80, true}, {"", 90, false}, {"C", 70, true}})
+	want := []Network{{"A", 80, true}, {"B", 70, false}, {"C", 70, true}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestProvisioner(t *testing.T) {
+	backend := &fakeBackend{networks: []Network{{"Home", 60, true}, {"<Guest>", 30, false}}}
+	done := make(chan error, 1)
+	p := New(Options{Backend: backend, Passphrase: "radio1234", Listen: "127.0.0.1:0",
+		Done: func(ssid string, err error) { done <- err }})
+	if err := p.Apply("Home", "secret123"); err == nil {
+		t.Error("applied while inactive")
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Active() || len(p.Networks()) != 2 {
+		t.Fatalf("unexpected state %v %v", p.Active(), p.Networks())
+	}
+	if err := p.Start(); err != ErrActive {
+		t.Errorf("got %v, want ErrActive", err)
+	}
+	backend.expect(t, "scan", "start piradio-setup radio1234")
+
+	server := httptest.NewServer(p.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/generate_204")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if !strings.Contains(string(body), `<option value="Home">Home (60%, secured)</option>`) ||
+		!strings.Contains(string(body), "&lt;Guest&gt;") {
+		t.Errorf("unexpected page %s", body)
+	}
+
+	resp, err = http.PostForm(server.URL, url.Values{"ssid": {"Home"}, "psk": {"short"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid passphrase", resp.StatusCode)
+	}
+
+	resp, err = http.PostForm(server.URL, url.Values{"ssid": {""}, "other": {" Hidden "}, "psk": {"secret123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d", resp.StatusCode)
+	}
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("credentials not applied")
+	}
+	if p.Active() {
+		t.Error("still active")
+	}
+	backend.expect(t, "scan", "start piradio-setup radio1234", "stop", "connect Hidden secret123")
+}
+
+func TestProvisionerFailures(t *testing.T) {
+	backend := &fakeBackend{scanErr: errors.New("busy"), connectErr: errors.New("no such network")}
+	p := New(Options{Backend: backend, Passphrase: "radio1234", Listen: "127.0.0.1:0"})
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Networks()) != 0 {
+		t.Errorf("unexpected networks %v", p.Networks())
+	}
+	if err := p.Apply("Home", "secret123"); err == nil {
+		t.Error("expected the error of the backend")
+	}
+	// the mode can be started again
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Stop(); err != nil || p.Active() {
+		t.Errorf("Stop: %v %v", err, p.Active())
+	}
+	backend.expect(t, "scan", "start piradio-setup radio1234", "stop", "connect Home secret123", "scan",
+		"start piradio-setup radio1234", "stop")
+}
+
+func TestPassphrase(t *testing.T) {
+	backend := &fakeBackend{}
+	p := New(Options{Backend: backend, Listen: "127.0.0.1:0"})
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	passphrase := p.Passphrase()
+	if len(passphrase) != 8 || strings.Trim(passphrase, passphraseChars) != "" {
+		t.Errorf("unexpected passphrase %q", passphrase)
+	}
+	backend.expect(t, "scan", "start piradio-setup "+passphrase)
+	if err := p.Stop(); err != nil || p.Passphrase() != "" {
+		t.Errorf("Stop: %v %q", err, p.Passphrase())
+	}
+
+	p = New(Options{Backend: backend, Passphrase: "short", Listen: "127.0.0.1:0"})
+	if err := p.Start(); err == nil || p.Active() {
+		t.Error("started with a short passphrase")
+	}
+}
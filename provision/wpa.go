@@ -0,0 +1,286 @@
+package provision
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aluedtke7/piradio/bluetooth"
+)
+
+// Wpa is the backend for wpa_supplicant and hostapd. The access point needs a static address and a DHCP server
+// (e.g. dnsmasq) for the interface, which are usually configured together with hostapd.
+type Wpa struct {
+	Run         bluetooth.Runner // defaults to bluetooth.ExecRunner
+	Interface   string           // defaults to "wlan0"
+	ConfigFile  string           // defaults to "/etc/wpa_supplicant/wpa_supplicant.conf"
+	HostapdFile string           // defaults to "/etc/hostapd/hostapd.conf"
+	StartCmd    []string         // starts the access point; defaults to "systemctl start hostapd"
+	StopCmd     []string         // stops the access point; defaults to "systemctl stop hostapd"
+}
+
+func (w *Wpa) defaults() {
+	if w.Run == nil {
+		w.Run = bluetooth.ExecRunner
+	}
+	if w.Interface == "" {
+		w.Interface = "wlan0"
+	}
+	if w.ConfigFile == "" {
+		w.ConfigFile = "/etc/wpa_supplicant/wpa_supplicant.conf"
+	}
+	if w.HostapdFile == "" {
+		w.HostapdFile = "/etc/hostapd/hostapd.conf"
+	}
+	if len(w.StartCmd) == 0 {
+		w.StartCmd = []string{"systemctl", "start", "hostapd"}
+	}
+	if len(w.StopCmd) == 0 {
+		w.StopCmd = []string{"systemctl", "stop", "hostapd"}
+	}
+}
+
+func (w *Wpa) Scan() ([]Network, error) {
+	w.defaults()
+	out, err := w.Run("iw", "dev", w.Interface, "scan")
+	if err != nil {
+		return nil, err
+	}
+	return ParseIwScan(string(out)), nil
+}
+
+func (w *Wpa) StartAP(ssid, passphrase string) error {
+	w.defaults()
+	if passphrase == "" {
+		return errors.New("the access point needs a passphrase")
+	}
+	if err := writeFile(w.HostapdFile, HostapdConfig(w.Interface, ssid, passphrase), 0600); err != nil {
+		return err
+	}
+	// wpa_supplicant must release the interface
+	_, _ = w.Run("wpa_cli", "-i", w.Interface, "disconnect")
+	_, err := w.Run(w.StartCmd[0], w.StartCmd[1:]...)
+	return err
+}
+
+func (w *Wpa) StopAP() error {
+	w.defaults()
+	if _, err := w.Run(w.StopCmd[0], w.StopCmd[1:]...); err != nil {
+		return err
+	}
+	_, err := w.Run("wpa_cli", "-i", w.Interface, "reconnect")
+	return err
+}
+
+func (w *Wpa) Connect(ssid, psk string) error {
+	w.defaults()
+	content, err := ioutil.ReadFile(w.ConfigFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err = writeFile(w.ConfigFile, UpdateWpaConfig(string(content), ssid, psk), 0600); err != nil {
+		return err
+	}
+	if _, err = w.Run("wpa_cli", "-i", w.Interface, "reconfigure"); err != nil {
+		return err
+	}
+	_, err = w.Run("wpa_cli", "-i", w.Interface, "reconnect")
+	return err
+}
+
+/**
+  Returns the configuration of hostapd for a WPA2 access point on the interface
+*/
+func HostapdConfig(iface, ssid, passphrase string) string {
+	var b strings.Builder
+	b.WriteString("interface=" + iface + "\n")
+	b.WriteString("driver=nl80211\n")
+	b.WriteString("ssid2=" + quoteSSID(ssid) + "\n")
+	b.WriteString("hw_mode=g\nchannel=6\nwmm_enabled=0\nmacaddr_acl=0\nignore_broadcast_ssid=0\n")
+	b.WriteString("auth_algs=1\nwpa=2\nwpa_key_mgmt=WPA-PSK\nrsn_pairwise=CCMP\n")
+	b.WriteString("wpa_passphrase=" + passphrase + "\n")
+	return b.String()
+}
+
+/**
+  Returns the configuration of wpa_supplicant with the network added. A network block with the same SSID is
+  replaced, the rest of the configuration is kept. The new network gets a higher priority than the others.
+*/
+func UpdateWpaConfig(content, ssid, psk string) string {
+	var b strings.Builder
+	priority := 0
+	removed := false
+	for _, block := range splitBlocks(content) {
+		if strings.HasPrefix(strings.TrimSpace(block), "network={") {
+			if blockValue(block, "ssid") == quoteSSID(ssid) {
+				removed = true
+				continue
+			}
+			p, _ := strconv.Atoi(blockValue(block, "priority"))
+			if p >= priority {
+				priority = p + 1
+			}
+		} else if removed {
+			// the empty lines after a removed block
+			block = strings.TrimLeft(block, "\n")
+		}
+		removed = false
+		b.WriteString(block)
+	}
+	if b.Len() == 0 {
+		b.WriteString("ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev\nupdate_config=1\n")
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\nnetwork={\n")
+	b.WriteString("\tssid=" + quoteSSID(ssid) + "\n")
+	switch {
+	case psk == "":
+		b.WriteString("\tkey_mgmt=NONE\n")
+	case isHexKey(psk):
+		b.WriteString("\tpsk=" + strings.ToLower(psk) + "\n")
+	default:
+		b.WriteString("\tpsk=\"" + psk + "\"\n")
+	}
+	if priority > 0 {
+		b.WriteString("\tpriority=" + strconv.Itoa(priority) + "\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// returns the SSID in quotes or as hex digits, when it contains characters that can't be quoted
+func quoteSSID(ssid string) string {
+	for _, c := range ssid {
+		if c < 32 || c > 126 || c == '"' {
+			return hex.EncodeToString([]byte(ssid))
+		}
+	}
+	return "\"" + ssid + "\""
+}
+
+// splits the configuration into the network blocks and the text between them
+func splitBlocks(content string) []string {
+	var blocks []string
+	var current strings.Builder
+	inNetwork := false
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inNetwork && strings.HasPrefix(trimmed, "network={") {
+			if current.Len() > 0 {
+				blocks = append(blocks, current.String())
+				current.Reset()
+			}
+			inNetwork = true
+		}
+		current.WriteString(line)
+		if inNetwork && trimmed == "}" {
+			blocks = append(blocks, current.String())
+			current.Reset()
+			inNetwork = false
+		}
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+	return blocks
+}
+
+// returns the value of the key in a network block
+func blockValue(block, key string) string {
+	for _, line := range strings.Split(block, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) == 2 && kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+/**
+  Parses the output of 'iw dev wlan0 scan'. The signal in dBm is converted to percent (-100 dBm is 0%,
+  -50 dBm and more is 100%).
+*/
+func ParseIwScan(out string) []Network {
+	var networks []Network
+	var current *Network
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "BSS "):
+			networks = append(networks, Network{})
+			current = &networks[len(networks)-1]
+		case current == nil:
+		case strings.HasPrefix(trimmed, "SSID: "):
+			current.SSID = unescapeIw(strings.TrimPrefix(trimmed, "SSID: "))
+		case strings.HasPrefix(trimmed, "signal: "):
+			fields := strings.Fields(strings.TrimPrefix(trimmed, "signal: "))
+			if dbm, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				current.Signal = dbmToPercent(dbm)
+			}
+		case strings.HasPrefix(trimmed, "RSN:") || strings.HasPrefix(trimmed, "WPA:") ||
+			strings.HasPrefix(trimmed, "capability:") && strings.Contains(trimmed, "Privacy"):
+			current.Secure = true
+		}
+	}
+	return networks
+}
+
+// iw escapes non-printable characters as '\xNN'
+func unescapeIw(s string) string {
+	if !strings.Contains(s, `\x`) {
+		return s
+	}
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				b = append(b, byte(v))
+				i += 3
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func dbmToPercent(dbm float64) int {
+	p := int(2 * (dbm + 100))
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+// writes the file via a temporary file, so that a crash doesn't leave a partial file
+func writeFile(fileName, content string, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), fileName); err != nil {
+		return fmt.Errorf("writing %s failed: %w", fileName, err)
+	}
+	return nil
+}
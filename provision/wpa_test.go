@@ -0,0 +1,159 @@
+package provision
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const wpaConfig = `ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev
+update_config=1
+country=DE
+
+network={
+	ssid="Home"
+	psk="oldsecret"
+	priority=2
+}
+
+network={
+	ssid="Office"
+	psk="office123"
+}
+`
+
+func TestUpdateWpaConfig(t *testing.T) {
+	got := UpdateWpaConfig(wpaConfig, "Home", "newsecret")
+	want := `ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev
+update_config=1
+country=DE
+
+network={
+	ssid="Office"
+	psk="office123"
+}
+
+network={
+	ssid="Home"
+	psk="newsecret"
+	priority=1
+}
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got = UpdateWpaConfig(wpaConfig, "Café", "")
+	if !strings.Contains(got, "ssid=\"Home\"") || !strings.Contains(got, "\tssid=436166c3a9\n\tkey_mgmt=NONE\n\tpriority=3\n") {
+		t.Errorf("unexpected config\n%s", got)
+	}
+
+	got = UpdateWpaConfig("", "Home", strings.Repeat("AB", 32))
+	want = "ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev\nupdate_config=1\n\nnetwork={\n\tssid=\"Home\"\n\tpsk=" +
+		strings.Repeat("ab", 32) + "\n}\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHostapdConfig(t *testing.T) {
+	got := HostapdConfig("wlan0", "piradio-setup", "radio1234")
+	for _, line := range []string{"interface=wlan0", `ssid2="piradio-setup"`, "wpa=2", "wpa_passphrase=radio1234"} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("missing %s in\n%s", line, got)
+		}
+	}
+}
+
+func TestParseIwScan(t *testing.T) {
+	out := `BSS 11:22:33:44:55:66(on wlan0)
+	freq: 2412
+	capability: ESS Privacy ShortSlotTime (0x0411)
+	signal: -48.00 dBm
+	SSID: Home
+	RSN:	 * Version: 1
+BSS aa:bb:cc:dd:ee:ff(on wlan0)
+	capability: ESS ShortSlotTime (0x0401)
+	signal: -81.00 dBm
+	SSID: Caf\xc3\xa9
+BSS 00:11:22:33:44:55(on wlan0)
+	signal: -101.00 dBm
+	SSID: 
+`
+	got := ParseIwScan(out)
+	want := []Network{{"Home", 100, true}, {"Café", 38, false}, {"", 0, false}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got[i], want[i])
+		}
+	}
+}
+
+func TestWpaBackend(t *testing.T) {
+	dir := t.TempDir()
+	var calls []string
+	w := &Wpa{
+		Run: func(name string, args ...string) ([]byte, error) {
+			calls = append(calls, name+" "+strings.Join(args, " "))
+			return nil, nil
+		},
+		ConfigFile:  filepath.Join(dir, "wpa_supplicant.conf"),
+		HostapdFile: filepath.Join(dir, "hostapd.conf"),
+	}
+	if err := ioutil.WriteFile(w.ConfigFile, []byte(wpaConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.StartAP("piradio-setup", ""); err == nil {
+		t.Error("started an open access point")
+	}
+	if err := w.StartAP("piradio-setup", "radio1234"); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ioutil.ReadFile(w.HostapdFile); err != nil || !strings.Contains(string(content), "ssid2=") {
+		t.Errorf("hostapd config not written: %v", err)
+	}
+	if err := w.StopAP(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Connect("Office", "office456"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(w.ConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "psk=\"office456\"") || strings.Contains(string(content), "office123") {
+		t.Errorf("unexpected config\n%s", content)
+	}
+	if fi, err := os.Stat(w.ConfigFile); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode %v %v", fi.Mode(), err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("temporary files left: %v", files)
+	}
+	want := []string{"wpa_cli -i wlan0 disconnect", "systemctl start hostapd", "systemctl stop hostapd",
+		"wpa_cli -i wlan0 reconnect", "wpa_cli -i wlan0 reconfigure", "wpa_cli -i wlan0 reconnect"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("got calls %q, want %q", calls, want)
+	}
+}
+
+func TestParseNmcliList(t *testing.T) {
+	out := "Home:75:WPA2\nMy\\:Net:40:\n:20:WPA2\nGuest:30:--\n"
+	got := ParseNmcliList(out)
+	want := []Network{{"Home", 75, true}, {"My:Net", 40, false}, {"Guest", 30, false}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package selector
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options configure the Selector. The callbacks are called without holding a lock, Tune and Abort are called
+// from the goroutine of a timer when the selection times out.
+type Options struct {
+	Stations     int               // number of stations
+	DigitTimeout time.Duration     // time after the last digit until the station is tuned
+	BrowseDelay  time.Duration     // time after the last browse step until the station is tuned
+	Show         func(text string) // shows the pending selection, e.g. "Station: 2_"
+	Preview      func(idx int)     // shows the name of the station that is browsed to
+	Tune         func(idx int)     // tunes the selected station
+	Abort        func()            // restores the display after an invalid or canceled selection
+}
+
+// Selector selects a station by entering its number or by browsing the station names. The station is only tuned
+// after a timeout or on confirmation, so that the stations in between aren't started.
+type Selector struct {
+	opts     Options
+	mutex    sync.Mutex
+	digits   string
+	browsing bool
+	idx      int
+	timer    *time.Timer
+	gen      int // incremented with every change, so that a stale timer does nothing
+}
+
+/**
+  Returns a selector for the given number of stations
+*/
+func New(opts Options) *Selector {
+	return &Selector{opts: opts}
+}
+
+// Digit adds a digit to the station number. The station is tuned immediately, when no further digit is possible.
+func (s *Selector) Digit(d int) {
+	s.mutex.Lock()
+	s.browsing = false
+	if len(s.digits) >= len(strconv.Itoa(s.opts.Stations)) {
+		s.digits = ""
+	}
+	s.digits += strconv.Itoa(d)
+	nr, _ := strconv.Atoi(s.digits)
+	if nr*10 > s.opts.Stations || len(s.digits) >= len(strconv.Itoa(s.opts.Stations)) {
+		s.mutex.Unlock()
+		s.Confirm()
+		return
+	}
+	text := "Station: " + s.digits + "_"
+	s.schedule(s.opts.DigitTimeout)
+	s.mutex.Unlock()
+	s.opts.Show(text)
+}
+
+// Browse moves the selection 'n' stations forward (or backward for negative values). The first step starts
+// at the station 'current'.
+func (s *Selector) Browse(current, n int) {
+	if s.opts.Stations == 0 {
+		return
+	}
+	s.mutex.Lock()
+	if !s.browsing {
+		s.browsing = true
+		s.digits = ""
+		s.idx = current
+	}
+	s.idx = ((s.idx+n)%s.opts.Stations + s.opts.Stations) % s.opts.Stations
+	idx := s.idx
+	s.schedule(s.opts.BrowseDelay)
+	s.mutex.Unlock()
+	s.opts.Preview(idx)
+	s.opts.Show("Station: " + strconv.Itoa(idx+1))
+}
+
+// Confirm tunes the pending selection immediately. Returns false, when there's no pending selection.
+func (s *Selector) Confirm() bool {
+	s.mutex.Lock()
+	idx, ok, pending := s.take()
+	s.mutex.Unlock()
+	if pending {
+		s.finish(idx, ok)
+	}
+	return pending
+}
+
+// Cancel discards the pending selection
+func (s *Selector) Cancel() {
+	s.mutex.Lock()
+	_, _, pending := s.take()
+	s.mutex.Unlock()
+	if pending {
+		s.opts.Abort()
+	}
+}
+
+// Pending returns true while a station number is entered or the stations are browsed
+func (s *Selector) Pending() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.browsing || s.digits != ""
+}
+
+// (re)starts the timer; must be called with the lock held
+func (s *Selector) schedule(d time.Duration) {
+	s.gen++
+	gen := s.gen
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(d, func() {
+		s.mutex.Lock()
+		if gen != s.gen {
+			s.mutex.Unlock()
+			return
+		}
+		idx, ok, pending := s.take()
+		s.mutex.Unlock()
+		if pending {
+			s.finish(idx, ok)
+		}
+	})
+}
+
+// returns and resets the pending selection; must be called with the lock held
+func (s *Selector) take() (idx int, ok bool, pending bool) {
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	switch {
+	case s.browsing:
+		s.browsing = false
+		return s.idx, true, true
+	case s.digits != "":
+		nr, _ := strconv.Atoi(s.digits)
+		s.digits = ""
+		return nr - 1, nr >= 1 && nr <= s.opts.Stations, true
+	}
+	return 0, false, false
+}
+
+func (s *Selector) finish(idx int, ok bool) {
+	if ok {
+		s.opts.Tune(idx)
+	} else {
+		s.opts.Abort()
+	}
+}
@@ -0,0 +1,156 @@
+package selector
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// records the calls of the callbacks
+type recorder struct {
+	mutex  sync.Mutex
+	shown  []string
+	viewed []int
+	tuned  chan int
+	aborts chan bool
+}
+
+func newSelector(stations int) (*Selector, *recorder) {
+	r := &recorder{tuned: make(chan int, 10), aborts: make(chan bool, 10)}
+	s := New(Options{
+		Stations:     stations,
+		DigitTimeout: 30 * time.Millisecond,
+		BrowseDelay:  30 * time.Millisecond,
+		Show: func(text string) {
+			r.mutex.Lock()
+			r.shown = append(r.shown, text)
+			r.mutex.Unlock()
+		},
+		Preview: func(idx int) {
+			r.mutex.Lock()
+			r.viewed = append(r.viewed, idx)
+			r.mutex.Unlock()
+		},
+		Tune:  func(idx int) { r.tuned <- idx },
+		Abort: func() { r.aborts <- true },
+	})
+	return s, r
+}
+
+func (r *recorder) expectTuned(t *testing.T, want int) {
+	t.Helper()
+	select {
+	case idx := <-r.tuned:
+		if idx != want {
+			t.Errorf("tuned %d, want %d", idx, want)
+		}
+	case <-r.aborts:
+		t.Errorf("aborted, want %d", want)
+	case <-time.After(time.Second):
+		t.Errorf("timeout, want %d", want)
+	}
+}
+
+func (r *recorder) expectAbort(t *testing.T) {
+	t.Helper()
+	select {
+	case idx := <-r.tuned:
+		t.Errorf("tuned %d, want abort", idx)
+	case <-r.aborts:
+	case <-time.After(time.Second):
+		t.Error("timeout, want abort")
+	}
+}
+
+func (r *recorder) lastShown() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.shown) == 0 {
+		return ""
+	}
+	return r.shown[len(r.shown)-1]
+}
+
+func TestDigitTimeout(t *testing.T) {
+	s, r := newSelector(27)
+	s.Digit(2)
+	if got := r.lastShown(); got != "Station: 2_" {
+		t.Errorf("shown %q", got)
+	}
+	if !s.Pending() {
+		t.Error("selection must be pending")
+	}
+	r.expectTuned(t, 1)
+	if s.Pending() {
+		t.Error("selection must not be pending")
+	}
+}
+
+func TestDigitComplete(t *testing.T) {
+	s, r := newSelector(27)
+	// no station 30 or higher: tuned without waiting
+	s.Digit(3)
+	r.expectTuned(t, 2)
+	s.Digit(2)
+	s.Digit(0)
+	r.expectTuned(t, 19)
+	s.Digit(2)
+	s.Digit(8)
+	r.expectAbort(t)
+	s.Digit(0)
+	s.Digit(0)
+	r.expectAbort(t)
+}
+
+func TestDigitLeadingZero(t *testing.T) {
+	s, r := newSelector(120)
+	s.Digit(0)
+	s.Digit(1)
+	if got := r.lastShown(); got != "Station: 01_" {
+		t.Errorf("shown %q", got)
+	}
+	s.Digit(5)
+	r.expectTuned(t, 14)
+}
+
+func TestConfirmAndCancel(t *testing.T) {
+	s, r := newSelector(27)
+	if s.Confirm() {
+		t.Error("nothing to confirm")
+	}
+	s.Digit(2)
+	if !s.Confirm() {
+		t.Error("confirm failed")
+	}
+	r.expectTuned(t, 1)
+	s.Digit(1)
+	s.Cancel()
+	r.expectAbort(t)
+	time.Sleep(60 * time.Millisecond)
+	select {
+	case idx := <-r.tuned:
+		t.Errorf("canceled selection tuned %d", idx)
+	default:
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	s, r := newSelector(5)
+	s.Browse(3, 1)
+	s.Browse(3, 1)
+	if got := r.lastShown(); got != "Station: 1" {
+		t.Errorf("shown %q", got)
+	}
+	s.Browse(3, -2)
+	r.expectTuned(t, 3)
+	r.mutex.Lock()
+	if len(r.viewed) != 3 || r.viewed[0] != 4 || r.viewed[1] != 0 || r.viewed[2] != 3 {
+		t.Errorf("previews %v", r.viewed)
+	}
+	r.mutex.Unlock()
+
+	// a digit ends browsing
+	s.Browse(0, 1)
+	s.Digit(4)
+	r.expectTuned(t, 3)
+}
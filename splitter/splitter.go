@@ -0,0 +1,128 @@
+package splitter
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strategy defines how the StreamTitle is split into artist and title
+type Strategy string
+
+const (
+	Auto             Strategy = "auto"               // uses heuristics to find the best strategy
+	ArtistTitle      Strategy = "artist-title"       // "Artist - Title"
+	TitleArtist      Strategy = "title-artist"       // "Title - Artist"
+	ArtistSlashTitle Strategy = "artist/title"       // "Artist / Title"
+	TitleByArtist    Strategy = "title-by-artist"    // "Title by Artist"
+	ArtistTitleAlbum Strategy = "artist-title-album" // "Artist - Title - Album", the album is dropped
+	None             Strategy = "none"               // no split, the text is shown in a single line
+)
+
+var strategies = []Strategy{Auto, ArtistTitle, TitleArtist, ArtistSlashTitle, TitleByArtist, ArtistTitleAlbum, None}
+
+// Parse returns the strategy with the given name
+func Parse(name string) (Strategy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, s := range strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return None, fmt.Errorf("unknown split strategy %s", name)
+}
+
+/**
+  Splits the text into artist and title by the given strategy. When the text can't be split, the result ok is
+  false and the text should be shown in a single line.
+*/
+func Split(strategy Strategy, text string) (artist string, title string, ok bool) {
+	switch strategy {
+	case Auto:
+		return Split(guess(text), text)
+	case ArtistTitle:
+		return cut(text, " - ", false)
+	case TitleArtist:
+		return cut(text, " - ", true)
+	case ArtistSlashTitle:
+		return cut(text, " / ", false)
+	case TitleByArtist:
+		idx := strings.LastIndex(strings.ToLower(text), " by ")
+		if idx <= 0 {
+			return "", "", false
+		}
+		return check(text[idx+4:], text[:idx])
+	case ArtistTitleAlbum:
+		parts := strings.Split(text, " - ")
+		if len(parts) < 3 {
+			return cut(text, " - ", false)
+		}
+		return check(parts[0], parts[1])
+	}
+	return "", "", false
+}
+
+// guesses the strategy by looking at the separators found in the text
+func guess(text string) Strategy {
+	lower := strings.ToLower(text)
+	switch strings.Count(text, " - ") {
+	case 0:
+		if strings.Contains(text, " / ") {
+			return ArtistSlashTitle
+		}
+		if isTitleByArtist(text) {
+			return TitleByArtist
+		}
+		return None
+	case 1:
+		// the artist usually contains the featured artists: "Title - Artist feat. Other Artist"
+		idx := strings.Index(lower, " - ")
+		if isFeaturing(lower[idx+3:]) && !isFeaturing(lower[:idx]) {
+			return TitleArtist
+		}
+		return ArtistTitle
+	default:
+		return ArtistTitleAlbum
+	}
+}
+
+// returns true if the text looks like "Title by Artist". The 'by' must be lowercase and both parts must have more
+// than one word or at least 4 characters, so that titles like "Stand By Me" or "Stand by Me" aren't split.
+func isTitleByArtist(text string) bool {
+	idx := strings.LastIndex(strings.ToLower(text), " by ")
+	if idx <= 0 || text[idx:idx+4] != " by " {
+		return false
+	}
+	return isPart(text[:idx]) && isPart(text[idx+4:])
+}
+
+func isPart(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(strings.Fields(s)) > 1 || utf8.RuneCountInString(s) >= 4
+}
+
+func isFeaturing(s string) bool {
+	return strings.Contains(s, " feat.") || strings.Contains(s, " ft.") || strings.Contains(s, " featuring ")
+}
+
+func cut(text string, sep string, swap bool) (string, string, bool) {
+	idx := strings.Index(text, sep)
+	if idx <= 0 {
+		return "", "", false
+	}
+	first, second := text[:idx], text[idx+len(sep):]
+	if swap {
+		return check(second, first)
+	}
+	return check(first, second)
+}
+
+// both parts must contain something, otherwise the text is shown in a single line
+func check(artist string, title string) (string, string, bool) {
+	artist = strings.TrimSpace(artist)
+	title = strings.TrimSpace(title)
+	if len(artist) == 0 || len(title) == 0 {
+		return "", "", false
+	}
+	return artist, title, true
+}
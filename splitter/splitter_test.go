@@ -0,0 +1,60 @@
+package splitter
+
+import (
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		text     string
+		artist   string
+		title    string
+		ok       bool
+	}{
+		{ArtistTitle, "Sting - Fragile", "Sting", "Fragile", true},
+		{ArtistTitle, "Sting - Fragile - Live", "Sting", "Fragile - Live", true},
+		{ArtistTitle, "Sting", "", "", false},
+		{ArtistTitle, " - ", "", "", false},
+		{ArtistTitle, "Sting - ", "", "", false},
+		{TitleArtist, "Fragile - Sting", "Sting", "Fragile", true},
+		{TitleArtist, "Fragile", "", "", false},
+		{ArtistSlashTitle, "Sting / Fragile", "Sting", "Fragile", true},
+		{ArtistSlashTitle, "AC/DC / Thunderstruck", "AC/DC", "Thunderstruck", true},
+		{ArtistSlashTitle, "Sting - Fragile", "", "", false},
+		{TitleByArtist, "Fragile by Sting", "Sting", "Fragile", true},
+		{TitleByArtist, "Stand By Me by Ben E. King", "Ben E. King", "Stand By Me", true},
+		{TitleByArtist, "Fragile", "", "", false},
+		{ArtistTitleAlbum, "Sting - Fragile - Nothing Like The Sun", "Sting", "Fragile", true},
+		{ArtistTitleAlbum, "Sting - Fragile", "Sting", "Fragile", true},
+		{None, "Sting - Fragile", "", "", false},
+		{Auto, "Sting - Fragile", "Sting", "Fragile", true},
+		{Auto, "Sting - Fragile - Nothing Like The Sun", "Sting", "Fragile", true},
+		{Auto, "Sting / Fragile", "Sting", "Fragile", true},
+		{Auto, "Fragile by Sting", "Sting", "Fragile", true},
+		{Auto, "Killing Me Softly by Fugees", "Fugees", "Killing Me Softly", true},
+		{Auto, "Stand By Me", "", "", false},
+		{Auto, "Stand by Me", "", "", false},
+		{Auto, "Bye Bye Bye", "", "", false},
+		{Auto, "Stand By Me by Ben E. King", "Ben E. King", "Stand By Me", true},
+		{Auto, "Hey Mama - David Guetta feat. Nicki Minaj", "David Guetta feat. Nicki Minaj", "Hey Mama", true},
+		{Auto, "David Guetta feat. Nicki Minaj - Hey Mama", "David Guetta feat. Nicki Minaj", "Hey Mama", true},
+		{Auto, "Radio Hamburg", "", "", false},
+	}
+	for _, tt := range tests {
+		artist, title, ok := Split(tt.strategy, tt.text)
+		if artist != tt.artist || title != tt.title || ok != tt.ok {
+			t.Errorf("Split(%s, %q) = %q, %q, %v", tt.strategy, tt.text, artist, title, ok)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse(" Title-Artist ")
+	if err != nil || s != TitleArtist {
+		t.Error("title-artist", s, err)
+	}
+	if _, err = Parse("artist+title"); err == nil {
+		t.Error("error expected")
+	}
+}
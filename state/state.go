@@ -0,0 +1,84 @@
+package state
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the volumes that are stored in the second and third line of the file, like in older versions
+const (
+	Analog    = "analog"
+	Bluetooth = "bluetooth"
+)
+
+// State is the state of the radio that is restored on the next start
+type State struct {
+	Station int               // index of the last station
+	Volumes map[string]string // volume per output device (e.g. "analog", a bluetooth address or an ALSA device)
+}
+
+/**
+  Loads the state from the file. The first line contains the station, the second and third line the volumes of
+  the analog output and of bluetooth. The volumes of the other output devices follow as 'device=volume'.
+*/
+func Load(fileName string) (State, error) {
+	s := State{Volumes: map[string]string{}}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return s, err
+	}
+	return Parse(string(content))
+}
+
+// Parse parses the content of the state file
+func Parse(content string) (State, error) {
+	s := State{Volumes: map[string]string{}}
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	var err error
+	if s.Station, err = strconv.Atoi(strings.TrimSpace(lines[0])); err != nil {
+		return s, fmt.Errorf("invalid station %q", lines[0])
+	}
+	for i, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		switch {
+		case i == 0 && !strings.Contains(line, "="):
+			s.Volumes[Analog] = line
+		case i == 1 && !strings.Contains(line, "="):
+			s.Volumes[Bluetooth] = line
+		default:
+			// the device may contain '=' (e.g. "alsa:device=hw=0.0"), the volume doesn't
+			if i := strings.LastIndex(line, "="); i > 0 && i < len(line)-1 {
+				s.Volumes[line[:i]] = line[i+1:]
+			}
+		}
+	}
+	return s, nil
+}
+
+// String returns the content of the state file
+func (s State) String() string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(s.Station) + "\n" + s.Volumes[Analog] + "\n" + s.Volumes[Bluetooth] + "\n")
+	var devices []string
+	for d := range s.Volumes {
+		if d != Analog && d != Bluetooth {
+			devices = append(devices, d)
+		}
+	}
+	sort.Strings(devices)
+	for _, d := range devices {
+		b.WriteString(d + "=" + s.Volumes[d] + "\n")
+	}
+	return b.String()
+}
+
+// Save writes the state to the file
+func (s State) Save(fileName string) error {
+	return ioutil.WriteFile(fileName, []byte(s.String()), 0644)
+}
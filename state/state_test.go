@@ -0,0 +1,56 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOldFormat(t *testing.T) {
+	s, err := Parse("4\n60\n30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Station != 4 || s.Volumes[Analog] != "60" || s.Volumes[Bluetooth] != "30" || len(s.Volumes) != 2 {
+		t.Errorf("unexpected state %+v", s)
+	}
+	s, err = Parse("2\n\n")
+	if err != nil || s.Station != 2 || len(s.Volumes) != 0 {
+		t.Errorf("unexpected state %+v, %v", s, err)
+	}
+	if _, err = Parse("abc"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "last_values")
+	s := State{Station: 7, Volumes: map[string]string{Analog: "55", Bluetooth: "35", "AA:BB:CC:DD:EE:FF": "20",
+		"11:22:33:44:55:66": "48"}}
+	if err := s.Save(fileName); err != nil {
+		t.Fatal(err)
+	}
+	if want := "7\n55\n35\n11:22:33:44:55:66=48\nAA:BB:CC:DD:EE:FF=20\n"; s.String() != want {
+		t.Errorf("got %q, want %q", s.String(), want)
+	}
+	loaded, err := Load(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Station != 7 || len(loaded.Volumes) != 4 || loaded.Volumes["AA:BB:CC:DD:EE:FF"] != "20" {
+		t.Errorf("unexpected state %+v", loaded)
+	}
+	if _, err = Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDeviceWithEquals(t *testing.T) {
+	s := State{Station: 1, Volumes: map[string]string{"alsa:device=hw=0.0": "55"}}
+	parsed, err := Parse(s.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Volumes) != 1 || parsed.Volumes["alsa:device=hw=0.0"] != "55" {
+		t.Errorf("unexpected volumes %v", parsed.Volumes)
+	}
+}
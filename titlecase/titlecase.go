@@ -0,0 +1,159 @@
+package titlecase
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultAcronyms are always written in uppercase letters
+var DefaultAcronyms = []string{"ABBA", "AOR", "BAP", "BBC", "BTS", "CD", "DJ", "DNA", "ELO", "EMF", "EP",
+	"FM", "INXS", "KC", "KLF", "LL", "LMFAO", "LP", "MC", "MGMT", "MTV", "NDR", "NRJ", "NYC", "OMD", "R.E.M.",
+	"SWR", "TLC", "TV", "UB40", "UFO", "UK", "USA", "WDR", "XTC", "ZZ"}
+
+// DefaultSmallWords are written in lowercase letters, unless they are the first word
+var DefaultSmallWords = []string{"a", "an", "and", "as", "at", "but", "by", "de", "feat.", "for", "ft.", "in",
+	"nor", "of", "on", "or", "the", "to", "van", "von", "vs", "vs."}
+
+// DefaultExceptions are written exactly as given
+var DefaultExceptions = []string{"AC/DC", "a-ha", "alt-J", "blink-182", "deadmau5", "t.A.T.u.", "will.i.am"}
+
+// Options configure the Caser. The lists are added to the default lists.
+type Options struct {
+	Acronyms      []string
+	SmallWords    []string
+	Exceptions    []string
+	RomanNumerals bool // write roman numerals (I to XXXIX) in uppercase letters
+}
+
+// Caser converts text to title case
+type Caser struct {
+	words         map[string]string // lowercase word -> fixed spelling (acronyms and exceptions)
+	small         map[string]bool
+	romanNumerals bool
+}
+
+var romanNumeral = regexp.MustCompile(`^(?i)x{0,3}(ix|iv|v?i{0,3})$`)
+
+const (
+	leadingPunct  = "\"'([{¿¡*"
+	trailingPunct = "\"')]}!?,;:*"
+)
+
+/**
+  Returns a title caser with the default lists and the given additions
+*/
+func New(opts Options) *Caser {
+	c := &Caser{words: map[string]string{}, small: map[string]bool{}, romanNumerals: opts.RomanNumerals}
+	for _, list := range [][]string{DefaultAcronyms, opts.Acronyms} {
+		for _, w := range list {
+			c.words[strings.ToLower(w)] = strings.ToUpper(w)
+		}
+	}
+	for _, list := range [][]string{DefaultExceptions, opts.Exceptions} {
+		for _, w := range list {
+			c.words[strings.ToLower(w)] = w
+		}
+	}
+	for _, list := range [][]string{DefaultSmallWords, opts.SmallWords} {
+		for _, w := range list {
+			c.small[strings.ToLower(w)] = true
+		}
+	}
+	return c
+}
+
+// Title converts every word of the text to title case. Small words are written in lowercase letters unless
+// they start the text or a part of it (after " - ", ":" or an opening bracket) or are the last word.
+func (c *Caser) Title(text string) string {
+	words := strings.Split(text, " ")
+	last := len(words) - 1
+	for last > 0 && len(words[last]) == 0 {
+		last--
+	}
+	first := true
+	for i, w := range words {
+		if len(w) == 0 {
+			continue
+		}
+		if strings.HasPrefix(w, "(") || strings.HasPrefix(w, "[") {
+			first = true
+		}
+		words[i] = c.word(w, first || i == last)
+		first = w == "-" || strings.HasSuffix(w, ":")
+	}
+	return strings.Join(words, " ")
+}
+
+// converts a single word that might be surrounded by punctuation
+func (c *Caser) word(w string, first bool) string {
+	lower := strings.ToLower(w)
+	if fixed, ok := c.words[lower]; ok {
+		return fixed
+	}
+	core := strings.TrimLeft(lower, leadingPunct)
+	prefix := lower[:len(lower)-len(core)]
+	core = strings.TrimRight(core, trailingPunct)
+	suffix := lower[len(prefix)+len(core):]
+	if len(core) == 0 {
+		return w
+	}
+	if fixed, ok := c.words[core]; ok {
+		return prefix + fixed + suffix
+	}
+	if c.small[core] && !first {
+		return prefix + core + suffix
+	}
+	return prefix + c.compound(core) + suffix
+}
+
+// capitalizes all parts of words like "hip-hop" or "rock/pop"
+func (c *Caser) compound(w string) string {
+	parts := strings.FieldsFunc(w, func(r rune) bool { return r == '-' || r == '/' })
+	if len(parts) < 2 {
+		return c.part(w)
+	}
+	var b strings.Builder
+	start := 0
+	for i, r := range w {
+		if r == '-' || r == '/' {
+			b.WriteString(c.part(w[start:i]))
+			b.WriteRune(r)
+			start = i + 1
+		}
+	}
+	b.WriteString(c.part(w[start:]))
+	return b.String()
+}
+
+func (c *Caser) part(p string) string {
+	if len(p) == 0 {
+		return p
+	}
+	if fixed, ok := c.words[p]; ok {
+		return fixed
+	}
+	if c.romanNumerals && romanNumeral.MatchString(p) {
+		return strings.ToUpper(p)
+	}
+	// Irish names like O'Connor or Italian names like D'Angelo
+	if idx := strings.Index(p, "'"); idx == 1 && (p[0] == 'o' || p[0] == 'd') && utf8.RuneCountInString(p) > 3 {
+		return strings.ToUpper(p[:1]) + "'" + capitalize(p[2:])
+	}
+	// Scottish names like McCartney
+	if strings.HasPrefix(p, "mc") && utf8.RuneCountInString(p) > 3 {
+		return "Mc" + capitalize(p[2:])
+	}
+	return capitalize(p)
+}
+
+// converts the first letter to uppercase, e.g. "'til" -> "'Til", "don't" -> "Don't"
+func capitalize(s string) string {
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return s[:i] + string(unicode.ToUpper(r)) + s[i+utf8.RuneLen(r):]
+		}
+	}
+	return s
+}
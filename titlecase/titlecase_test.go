@@ -0,0 +1,51 @@
+package titlecase
+
+import (
+	"testing"
+)
+
+func TestTitle(t *testing.T) {
+	c := New(Options{RomanNumerals: true, Acronyms: []string{"hh"}, Exceptions: []string{"iPhone"}})
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abc def ghi", "Abc Def Ghi"},
+		{"ABBA", "ABBA"},
+		{"abba - dancing queen", "ABBA - Dancing Queen"},
+		{"DJ BOBO", "DJ Bobo"},
+		{"ac/dc - highway to hell", "AC/DC - Highway to Hell"},
+		{"DANCING SEAHORSES FEAT. MARC HARTMAN", "Dancing Seahorses feat. Marc Hartman"},
+		{"the sound of silence", "The Sound of Silence"},
+		{"stairway to heaven (the remastered version)", "Stairway to Heaven (The Remastered Version)"},
+		{"ROCKY II: THE RETURN", "Rocky II: The Return"},
+		{"symphony no. ix", "Symphony No. IX"},
+		{"DON'T STOP ME NOW", "Don't Stop Me Now"},
+		{"SINEAD O'CONNOR", "Sinead O'Connor"},
+		{"'TIL TUESDAY", "'Til Tuesday"},
+		{"JAY-Z - HARD KNOCK LIFE", "Jay-Z - Hard Knock Life"},
+		{"HIP-HOP HOORAY", "Hip-Hop Hooray"},
+		{"A-HA - TAKE ON ME", "a-ha - Take on Me"},
+		{"paul mccartney", "Paul McCartney"},
+		{"r.e.m. - losing my religion", "R.E.M. - Losing My Religion"},
+		{"radio hh", "Radio HH"},
+		{"my iphone", "My iPhone"},
+		{"armin van buuren", "Armin van Buuren"},
+		{"double  space", "Double  Space"},
+		{"HOLD ON", "Hold On"},
+		{"ROCK/POP", "Rock/Pop"},
+	}
+	for _, tt := range tests {
+		got := c.Title(tt.in)
+		if got != tt.want {
+			t.Errorf("Title(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTitleWithoutRomanNumerals(t *testing.T) {
+	c := New(Options{SmallWords: []string{"with"}})
+	if s := c.Title("MIX WITH VI"); s != "Mix with Vi" {
+		t.Error("Mix with Vi", s)
+	}
+}
@@ -0,0 +1,53 @@
+package translit
+
+// Only lowercase letters are listed in the tables. Uppercase letters are converted via their lowercase counterpart.
+// Letters with diacritics that can be decomposed (e.g. 'é' -> 'e' + U+0301) don't need an entry, because
+// the decomposition is done automatically.
+
+// Latin letters that can't be decomposed
+var latinTable = map[rune]string{
+	'æ': "ae", 'ð': "d", 'ø': "o", 'þ': "th", 'ß': "ss", 'đ': "d", 'ħ': "h", 'ı': "i", 'ĸ': "q", 'ł': "l",
+	'ŋ': "ng", 'œ': "oe", 'ŧ': "t", 'ƀ': "b", 'ɓ': "b", 'ƈ': "c", 'ɗ': "d", 'ƒ': "f", 'ɠ': "g", 'ƙ': "k",
+	'ƚ': "l", 'ɲ': "n", 'ƞ': "n", 'ƥ': "p", 'ƫ': "t", 'ƭ': "t", 'ʈ': "t", 'ʋ': "v", 'ƴ': "y", 'ƶ': "z",
+	'ǝ': "e", 'ə': "e", 'ɛ': "e", 'ɔ': "o", 'ɨ': "i", 'ʉ': "u", 'ȥ': "z", 'ɂ': "'", 'ȼ': "c", 'ɇ': "e",
+	'ɉ': "j", 'ɍ': "r", 'ɏ': "y", 'ȸ': "db", 'ȹ': "qp", 'ǥ': "g", 'ǳ': "dz", 'ǆ': "dz", 'ǉ': "lj",
+	'ǌ': "nj", 'ĳ': "ij", 'ŀ': "l", 'ŉ': "'n", 'ſ': "s",
+}
+
+// Greek letters (ELOT 743), accented letters are decomposed
+var greekTable = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o", 'ϐ': "v", 'ϑ': "th", 'ϕ': "f", 'ϖ': "p",
+	'ϰ': "k", 'ϱ': "r", 'ϲ': "s", 'ϳ': "j", 'ϵ': "e",
+}
+
+// Cyrillic letters (Russian, Ukrainian, Belarusian, Serbian, Macedonian and Bulgarian)
+var cyrillicTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+	'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+	'ѐ': "e", 'ѝ': "i", 'ѣ': "e", 'ѳ': "f", 'ѵ': "i", 'ғ': "gh", 'қ': "q", 'ң': "ng", 'ү': "u",
+	'ұ': "u", 'һ': "h", 'ә': "a", 'ө': "o",
+}
+
+// punctuation and symbols
+var punctuationTable = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '´': "'", 'ʹ': "'", 'ʻ': "'", 'ʼ': "'", '′': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"", '«': "\"", '»': "\"", '‹': "'", '›': "'",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-", '…': "...", '•': "*",
+	'·': ".", '×': "x", '÷': "/", '⁄': "/", '¡': "!", '¿': "?", '©': "(c)", '®': "(R)", '€': "EUR",
+	'£': "GBP", '¥': "JPY", '¢': "c", '§': "S", '¶': "P", '°': "o", '±': "+-", '¦': "|", '¬': "-",
+	'♪': "*", '♫': "*", '★': "*", '☆': "*", '\u00a0': " ", '\u200b': "", '\ufeff': "",
+}
+
+// language specific translations, they take precedence over the generic tables
+var languageTables = map[string]map[rune]string{
+	"de": {'ä': "ae", 'ö': "oe", 'ü': "ue", 'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ẞ': "SS"},
+	"da": {'ø': "oe", 'å': "aa", 'Ø': "Oe", 'Å': "Aa"},
+	"no": {'ø': "oe", 'å': "aa", 'Ø': "Oe", 'Å': "Aa"},
+	"uk": {'г': "h", 'и': "y", 'й': "i", 'Г': "H", 'И': "Y", 'Й': "I"},
+	"bg": {'щ': "sht", 'ъ': "a", 'Щ': "Sht", 'Ъ': "A"},
+}
@@ -0,0 +1,96 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Translit converts unicode text into plain ascii text
+type Translit struct {
+	language map[rune]string
+}
+
+/**
+  Returns a transliterator for the given language (e.g. "de" translates 'ä' to "ae" instead of "a"). An empty
+  or unknown language uses the generic translation.
+*/
+func New(language string) *Translit {
+	return &Translit{language: languageTables[strings.ToLower(language)]}
+}
+
+// Normalize composes the text (NFC), so that a letter followed by combining accents is handled as one rune
+func Normalize(text string) string {
+	return norm.NFC.String(text)
+}
+
+// Rune returns the ascii translation of the given rune. The result is false, when no translation is known.
+// The translation might be an empty string for letters that are not pronounced (e.g. cyrillic 'ь').
+func (t *Translit) Rune(r rune) (string, bool) {
+	if r >= 32 && r <= 126 {
+		return string(r), true
+	}
+	if s, ok := t.language[r]; ok {
+		return s, true
+	}
+	if s, ok := lookup(r); ok {
+		return s, true
+	}
+	// decompose the rune and translate the remaining base runes without the combining marks
+	var b strings.Builder
+	decomposed := norm.NFKD.String(string(r))
+	if decomposed == string(r) {
+		return "", false
+	}
+	for _, d := range decomposed {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		if d >= 32 && d <= 126 {
+			b.WriteRune(d)
+			continue
+		}
+		s, ok := lookup(d)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(s)
+	}
+	return b.String(), true
+}
+
+// String returns the ascii translation of the whole text. Runes without a translation are dropped.
+func (t *Translit) String(text string) string {
+	var b strings.Builder
+	for _, r := range Normalize(text) {
+		s, _ := t.Rune(r)
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// searches the generic tables. Uppercase letters are translated via their lowercase letter.
+func lookup(r rune) (string, bool) {
+	if s, ok := lookupTables(r); ok {
+		return s, true
+	}
+	lower := unicode.ToLower(r)
+	if lower == r {
+		return "", false
+	}
+	s, ok := lookupTables(lower)
+	if !ok || len(s) == 0 {
+		return s, ok
+	}
+	return strings.ToUpper(s[:1]) + s[1:], true
+}
+
+func lookupTables(r rune) (string, bool) {
+	for _, table := range []map[rune]string{latinTable, greekTable, cyrillicTable, punctuationTable} {
+		if s, ok := table[r]; ok {
+			return s, true
+		}
+	}
+	return "", false
+}
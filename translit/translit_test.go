@@ -0,0 +1,56 @@
+package translit
+
+import (
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		language string
+		in       string
+		want     string
+	}{
+		{"", "abc", "abc"},
+		{"", "äöüß", "aouss"},
+		{"de", "äöüß", "aeoeuess"},
+		{"de", "ÄÖÜ", "AeOeUe"},
+		{"de", "über", "ueber"},
+		{"", "über", "uber"},
+		{"", "Dvořák", "Dvorak"},
+		{"", "Łódź", "Lodz"},
+		{"", "Kraków Zażółć gęślą jaźń", "Krakow Zazolc gesla jazn"},
+		{"", "Ağrı Dağı Şişli", "Agri Dagi Sisli"},
+		{"", "Ærøskøbing", "Aeroskobing"},
+		{"da", "Ærøskøbing Århus", "Aeroeskoebing Aarhus"},
+		{"", "Σωκράτης", "Sokratis"},
+		{"", "Άλφα Ωμέγα", "Alfa Omega"},
+		{"", "Щедрин Жуков", "Shchedrin Zhukov"},
+		{"", "Пётр Чайковский", "Pyotr Chaykovskiy"},
+		{"", "Київ", "Kiyiv"},
+		{"uk", "Київ", "Kyyiv"},
+		{"bg", "Щастие", "Shtastie"},
+		{"", "„Hello“ – ‘World’…", "\"Hello\" - 'World'..."},
+		{"", "Rock’n’Roll — Live", "Rock'n'Roll - Live"},
+		{"", "ﬁnal ½", "final 1/2"},
+		{"", "日本", ""},
+	}
+	for _, tt := range tests {
+		got := New(tt.language).String(tt.in)
+		if got != tt.want {
+			t.Errorf("String(%q, %q) = %q, want %q", tt.language, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRune(t *testing.T) {
+	tr := New("")
+	if s, ok := tr.Rune('ь'); !ok || s != "" {
+		t.Error("soft sign", s, ok)
+	}
+	if _, ok := tr.Rune('日'); ok {
+		t.Error("cjk must not be translated")
+	}
+	if s, ok := tr.Rune('x'); !ok || s != "x" {
+		t.Error("ascii", s, ok)
+	}
+}
@@ -0,0 +1,115 @@
+package wrap
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mode defines how texts that are too long for a single line are handled
+type Mode string
+
+const (
+	Wrap   Mode = "wrap"   // wraps on word boundaries across the available lines, scrolls if that doesn't fit
+	Scroll Mode = "scroll" // every text gets its own line and scrolls if it's too long
+)
+
+// Line is a line of the display. Scroll is true, when the text is too long for the line.
+type Line struct {
+	Text   string
+	Scroll bool
+}
+
+// ParseMode returns the mode with the given name
+func ParseMode(name string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(name))); m {
+	case Wrap, Scroll:
+		return m, nil
+	}
+	return Scroll, fmt.Errorf("unknown layout mode %s", name)
+}
+
+/**
+  Arranges artist and title on the given number of lines with the given width. The result always contains
+  'lines' entries. An empty artist means, that the title couldn't be split and is shown on its own.
+
+  In mode Wrap the following layouts are tried in this order:
+    - artist and title are wrapped separately, the artist lines are followed by the title lines
+    - "artist - title" is wrapped as one text
+    - artist and title get one scrolling line each (like mode Scroll)
+*/
+func Arrange(mode Mode, artist string, title string, width int, lines int) []Line {
+	if lines < 1 {
+		return nil
+	}
+	result := make([]Line, 0, lines)
+	combined := title
+	if len(artist) > 0 {
+		combined = artist + " - " + title
+	}
+	if lines == 1 {
+		return append(result, line(combined, width))
+	}
+	if mode == Wrap {
+		var wrapped []string
+		if len(artist) > 0 {
+			a, aOk := Lines(artist, width)
+			t, tOk := Lines(title, width)
+			if aOk && tOk && len(a)+len(t) <= lines {
+				wrapped = append(a, t...)
+			}
+		}
+		if wrapped == nil {
+			if c, ok := Lines(combined, width); ok && len(c) <= lines {
+				wrapped = c
+			}
+		}
+		if wrapped != nil {
+			for _, w := range wrapped {
+				result = append(result, Line{Text: w})
+			}
+			return pad(result, lines)
+		}
+	}
+	if len(artist) > 0 {
+		result = append(result, line(artist, width))
+	}
+	result = append(result, line(title, width))
+	return pad(result, lines)
+}
+
+/**
+  Wraps the text on word boundaries into lines that are not longer than width. The result ok is false, when
+  a single word is longer than width.
+*/
+func Lines(text string, width int) (lines []string, ok bool) {
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if utf8.RuneCountInString(word) > width {
+			return nil, false
+		}
+		if len(current) == 0 {
+			current = word
+		} else if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width {
+			current += " " + word
+		} else {
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if len(current) > 0 || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines, true
+}
+
+func line(text string, width int) Line {
+	return Line{Text: text, Scroll: utf8.RuneCountInString(text) > width}
+}
+
+func pad(result []Line, lines int) []Line {
+	for len(result) < lines {
+		result = append(result, Line{})
+	}
+	return result[:lines]
+}
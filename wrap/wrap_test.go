@@ -0,0 +1,77 @@
+package wrap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		lines []string
+		ok    bool
+	}{
+		{"Englishman in New York", 20, []string{"Englishman in New", "York"}, true},
+		{"Englishman in New York", 22, []string{"Englishman in New York"}, true},
+		{"  Englishman   in  ", 20, []string{"Englishman in"}, true},
+		{"Supercalifragilisticexpialidocious", 18, nil, false},
+		{"", 20, []string{""}, true},
+	}
+	for _, tt := range tests {
+		lines, ok := Lines(tt.text, tt.width)
+		if !reflect.DeepEqual(lines, tt.lines) || ok != tt.ok {
+			t.Errorf("Lines(%q, %d) = %q, %v", tt.text, tt.width, lines, ok)
+		}
+	}
+}
+
+func TestArrange(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   Mode
+		artist string
+		title  string
+		width  int
+		lines  int
+		want   []Line
+	}{
+		{"Fits", Wrap, "Sting", "Fragile", 20, 2,
+			[]Line{{Text: "Sting"}, {Text: "Fragile"}}},
+		{"WrapCombined", Wrap, "Sting", "Englishman in New York", 20, 2,
+			[]Line{{Text: "Sting - Englishman"}, {Text: "in New York"}}},
+		{"WrapSeparately", Wrap, "Sting", "Englishman in New York", 20, 4,
+			[]Line{{Text: "Sting"}, {Text: "Englishman in New"}, {Text: "York"}, {}}},
+		{"WrapTitleOnly", Wrap, "", "Radio Hamburg Nachrichten", 18, 2,
+			[]Line{{Text: "Radio Hamburg"}, {Text: "Nachrichten"}}},
+		{"WrapTitleOnlyTooLong", Wrap, "", "Radio Hamburg Nachrichten am Abend", 18, 2,
+			[]Line{{Text: "Radio Hamburg Nachrichten am Abend", Scroll: true}, {}}},
+		{"TooLongToWrap", Wrap, "Sting", "Englishman in New York and somewhere else", 20, 2,
+			[]Line{{Text: "Sting"}, {Text: "Englishman in New York and somewhere else", Scroll: true}}},
+		{"LongWord", Wrap, "", "Supercalifragilisticexpialidocious", 18, 2,
+			[]Line{{Text: "Supercalifragilisticexpialidocious", Scroll: true}, {}}},
+		{"Scroll", Scroll, "Sting", "Englishman in New York", 20, 2,
+			[]Line{{Text: "Sting"}, {Text: "Englishman in New York", Scroll: true}}},
+		{"SingleLine", Wrap, "Sting", "Fragile", 20, 1,
+			[]Line{{Text: "Sting - Fragile"}}},
+		{"SingleLineScroll", Wrap, "Sting", "Englishman in New York", 20, 1,
+			[]Line{{Text: "Sting - Englishman in New York", Scroll: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Arrange(tt.mode, tt.artist, tt.title, tt.width, tt.lines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := ParseMode("Wrap"); err != nil || m != Wrap {
+		t.Error("wrap", m, err)
+	}
+	if _, err := ParseMode("marquee"); err == nil {
+		t.Error("error expected")
+	}
+}